@@ -0,0 +1,130 @@
+/*
+ Package client provides the helpers to invoke this chaincode and to decode its responses.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+)
+
+// asWarning : return the WarningError when the payload is a WarningResult, or nil.
+func asWarning(payload []byte) *WarningError {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &fields); err != nil || len(fields) != 2 {
+		return nil
+	}
+	if _, ok := fields["status_code"]; !ok {
+		return nil
+	}
+	if _, ok := fields["message"]; !ok {
+		return nil
+	}
+	warning := new(WarningError)
+	if err := json.Unmarshal(trimmed, warning); err != nil {
+		return nil
+	}
+	return warning
+}
+
+// Decode : decode a JSON payload into v, or return a *WarningError when the payload is a WarningResult.
+//    v can be nil for the functions which return an empty payload.
+func Decode(payload []byte, v interface{}) error {
+	if warning := asWarning(payload); warning != nil {
+		return warning
+	}
+	if v == nil || len(bytes.TrimSpace(payload)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(payload, v)
+}
+
+// DecodeText : return a non-JSON payload such as CSV or XML, or a *WarningError when the payload is a WarningResult.
+func DecodeText(payload []byte) ([]byte, error) {
+	if warning := asWarning(payload); warning != nil {
+		return nil, warning
+	}
+	return payload, nil
+}
+
+// DecodeAccount : decode the payload of createAccount, retrieveAccount, updateAccountName and updateOverdraftLimit.
+func DecodeAccount(payload []byte) (*models.Account, error) {
+	account := new(models.Account)
+	if err := Decode(payload, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// DecodeAccounts : decode the payload of listAccount.
+func DecodeAccounts(payload []byte) ([]*models.Account, error) {
+	accounts := make([]*models.Account, 0)
+	if err := Decode(payload, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// DecodeAccountPage : decode the payload of searchAccount.
+func DecodeAccountPage(payload []byte) (*models.AccountPage, error) {
+	page := new(models.AccountPage)
+	if err := Decode(payload, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// DecodeEvent : decode the payload of deposit, remit, withdraw and reverseEvent.
+func DecodeEvent(payload []byte) (*models.Event, error) {
+	event := new(models.Event)
+	if err := Decode(payload, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// DecodeEvents : decode the payload of listEvent and applyInterest.
+func DecodeEvents(payload []byte) ([]*models.Event, error) {
+	events := make([]*models.Event, 0)
+	if err := Decode(payload, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// DecodeHistories : decode the payload of listHistory.
+func DecodeHistories(payload []byte) ([]*models.History, error) {
+	histories := make([]*models.History, 0)
+	if err := Decode(payload, &histories); err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
+
+// DecodeAccountAt : decode the payload of retrieveAccountAt.
+func DecodeAccountAt(payload []byte) (*models.AccountAt, error) {
+	accountAt := new(models.AccountAt)
+	if err := Decode(payload, accountAt); err != nil {
+		return nil, err
+	}
+	return accountAt, nil
+}
+
+// DecodeJournalPage : decode the payload of listJournal.
+func DecodeJournalPage(payload []byte) (*models.JournalPage, error) {
+	page := new(models.JournalPage)
+	if err := Decode(payload, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
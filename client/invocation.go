@@ -0,0 +1,294 @@
+/*
+ Package client provides the helpers to invoke this chaincode and to decode its responses.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+)
+
+// Invocation : a function name and its positional arguments.
+type Invocation struct {
+	Function string
+	Args     []string
+}
+
+// Strings : return the function name followed by the arguments, like the "Args" of peer chaincode invoke.
+func (i *Invocation) Strings() []string {
+	return append([]string{i.Function}, i.Args...)
+}
+
+// Bytes : return the function name followed by the arguments, like the Args of a fabric-sdk-go request.
+func (i *Invocation) Bytes() [][]byte {
+	strs := i.Strings()
+	args := make([][]byte, 0, len(strs))
+	for _, str := range strs {
+		args = append(args, []byte(str))
+	}
+	return args
+}
+
+// newInvocation : order the named arguments by the spec of the function.
+//    an empty value is treated as omitted. omitted optional arguments are filled by their defaults
+//    when followed by a given one, and trailing ones are dropped.
+func newInvocation(function string, named map[string]string) *Invocation {
+	specs, ok := params.Functions[function]
+	if !ok {
+		panic(fmt.Sprintf("unknown function, function = %s", function))
+	}
+	for name := range named {
+		found := false
+		for _, spec := range specs {
+			found = found || spec.Name == name
+		}
+		if !found {
+			panic(fmt.Sprintf("unknown parameter, function = %s, parameter = %s", function, name))
+		}
+	}
+
+	args := make([]string, len(specs))
+	last := -1
+	for i, spec := range specs {
+		value := named[spec.Name]
+		if value == "" && spec.Optional {
+			args[i] = spec.Default
+			continue
+		}
+		args[i] = value
+		last = i
+	}
+
+	n := params.Required(specs)
+	if last+1 > n {
+		n = last + 1
+	}
+	return &Invocation{Function: function, Args: args[:n]}
+}
+
+// optionalInt : convert an optional integer argument. zero is treated as omitted.
+func optionalInt(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
+// optionalFilter : convert an optional event filter to JSON.
+func optionalFilter(filter *models.EventFilter) (string, error) {
+	if filter == nil {
+		return "", nil
+	}
+	filterBytes, err := json.Marshal(filter)
+	if err != nil {
+		return "", err
+	}
+	return string(filterBytes), nil
+}
+
+// ListAccount : filter is '' or 'overdrawn', sort is like 'balance:desc'. both can be empty.
+func ListAccount(filter string, sort string) *Invocation {
+	return newInvocation("listAccount", map[string]string{"filter": filter, "sort": sort})
+}
+
+// SearchAccount : mode is 'substring' or 'prefix'. empty mode and zero limit use the defaults.
+func SearchAccount(term string, mode string, limit int, skip int) *Invocation {
+	return newInvocation("searchAccount", map[string]string{
+		"term":  term,
+		"mode":  mode,
+		"limit": optionalInt(limit),
+		"skip":  optionalInt(skip),
+	})
+}
+
+// CreateAccount : create an account.
+func CreateAccount(name string) *Invocation {
+	return newInvocation("createAccount", map[string]string{"name": name})
+}
+
+// RetrieveAccount : retrieve an account.
+func RetrieveAccount(no string) *Invocation {
+	return newInvocation("retrieveAccount", map[string]string{"no": no})
+}
+
+// UpdateAccountName : update the name of an account.
+func UpdateAccountName(no string, name string) *Invocation {
+	return newInvocation("updateAccountName", map[string]string{"no": no, "name": name})
+}
+
+// UpdateOverdraftLimit : update the overdraft limit of an account. the invoker must have the admin role.
+func UpdateOverdraftLimit(no string, overdraftLimit int) *Invocation {
+	return newInvocation("updateOverdraftLimit", map[string]string{"no": no, "overdraft_limit": strconv.Itoa(overdraftLimit)})
+}
+
+// UpdateAccountMetadata : update the customer and KYC metadata of an account. the invoker must have the compliance role.
+func UpdateAccountMetadata(no string, metadata *models.AccountMetadata) (*Invocation, error) {
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return newInvocation("updateAccountMetadata", map[string]string{"no": no, "metadata": string(metadataBytes)}), nil
+}
+
+// DeleteAccount : delete an account.
+func DeleteAccount(no string) *Invocation {
+	return newInvocation("deleteAccount", map[string]string{"no": no})
+}
+
+// ListEvent : list events matched with a filter. nil filter lists all events.
+func ListEvent(filter *models.EventFilter) (*Invocation, error) {
+	filterStr, err := optionalFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return newInvocation("listEvent", map[string]string{"filter": filterStr}), nil
+}
+
+// Deposit : memo and reference can be empty.
+func Deposit(toAccountNo string, amount int, memo string, reference string) *Invocation {
+	return newInvocation("deposit", map[string]string{
+		"to_account_no": toAccountNo,
+		"amount":        strconv.Itoa(amount),
+		"memo":          memo,
+		"reference":     reference,
+	})
+}
+
+// Remit : memo and reference can be empty.
+func Remit(fromAccountNo string, toAccountNo string, amount int, memo string, reference string) *Invocation {
+	return newInvocation("remit", map[string]string{
+		"from_account_no": fromAccountNo,
+		"to_account_no":   toAccountNo,
+		"amount":          strconv.Itoa(amount),
+		"memo":            memo,
+		"reference":       reference,
+	})
+}
+
+// Withdraw : memo and reference can be empty.
+func Withdraw(fromAccountNo string, amount int, memo string, reference string) *Invocation {
+	return newInvocation("withdraw", map[string]string{
+		"from_account_no": fromAccountNo,
+		"amount":          strconv.Itoa(amount),
+		"memo":            memo,
+		"reference":       reference,
+	})
+}
+
+// ReverseEvent : zero amount reverses the whole remaining amount. the invoker must have the admin or compliance role.
+func ReverseEvent(eventNo string, reason string, amount int) *Invocation {
+	return newInvocation("reverseEvent", map[string]string{
+		"event_no": eventNo,
+		"reason":   reason,
+		"amount":   optionalInt(amount),
+	})
+}
+
+// ListHistory : list the histories of a state object.
+func ListHistory(no string) *Invocation {
+	return newInvocation("listHistory", map[string]string{"no": no})
+}
+
+// RetrieveAccountAt : timestamp is RFC3339 or YYYY-MM-DD.
+func RetrieveAccountAt(no string, timestamp string) *Invocation {
+	return newInvocation("retrieveAccountAt", map[string]string{"no": no, "timestamp": timestamp})
+}
+
+// SetInterestRate : annualRate is in basis points.
+func SetInterestRate(accountType string, annualRate int) *Invocation {
+	return newInvocation("setInterestRate", map[string]string{"account_type": accountType, "annual_rate": strconv.Itoa(annualRate)})
+}
+
+// ApplyInterest : dates are YYYY-MM-DD.
+func ApplyInterest(fromDate string, toDate string) *Invocation {
+	return newInvocation("applyInterest", map[string]string{"from_date": fromDate, "to_date": toDate})
+}
+
+// GetStatement : the period is [from, to).
+func GetStatement(no string, from string, to string) *Invocation {
+	return newInvocation("getStatement", map[string]string{"no": no, "from": from, "to": to})
+}
+
+// GetCamt053Statement : the period is [from, to). decode the response by DecodeText.
+func GetCamt053Statement(no string, from string, to string) *Invocation {
+	return newInvocation("getCamt053Statement", map[string]string{"no": no, "from": from, "to": to})
+}
+
+// VerifyLedger : zero limit and zero skip verify all accounts at once.
+func VerifyLedger(limit int, skip int) *Invocation {
+	return newInvocation("verifyLedger", map[string]string{"limit": optionalInt(limit), "skip": optionalInt(skip)})
+}
+
+// ListJournal : empty accountNo lists all journals. zero limit uses the default.
+func ListJournal(accountNo string, limit int, skip int) *Invocation {
+	return newInvocation("listJournal", map[string]string{
+		"account_no": accountNo,
+		"limit":      optionalInt(limit),
+		"skip":       optionalInt(skip),
+	})
+}
+
+// RetrieveJournal : retrieve a journal.
+func RetrieveJournal(no string) *Invocation {
+	return newInvocation("retrieveJournal", map[string]string{"no": no})
+}
+
+// SummarizeEvents : groupBy is 'event_type', 'day' or 'account'.
+func SummarizeEvents(from string, to string, groupBy string) *Invocation {
+	return newInvocation("summarizeEvents", map[string]string{"from": from, "to": to, "group_by": groupBy})
+}
+
+// ListTopAccounts : from and to are empty when metric is 'balance'.
+func ListTopAccounts(metric string, n int, from string, to string) *Invocation {
+	return newInvocation("listTopAccounts", map[string]string{
+		"metric": metric,
+		"n":      strconv.Itoa(n),
+		"from":   from,
+		"to":     to,
+	})
+}
+
+// ExportEvents : nil filter exports all events. decode the response by DecodeText.
+func ExportEvents(filter *models.EventFilter, limit int, skip int) (*Invocation, error) {
+	filterStr, err := optionalFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return newInvocation("exportEvents", map[string]string{
+		"filter": filterStr,
+		"limit":  optionalInt(limit),
+		"skip":   optionalInt(skip),
+	}), nil
+}
+
+// ExportAccounts : filter is '' or 'overdrawn'. decode the response by DecodeText.
+func ExportAccounts(filter string, limit int, skip int) *Invocation {
+	return newInvocation("exportAccounts", map[string]string{
+		"filter": filter,
+		"limit":  optionalInt(limit),
+		"skip":   optionalInt(skip),
+	})
+}
+
+// MigrateState : modelType is 'account' or 'event'. zero limit uses the default.
+func MigrateState(modelType string, limit int) *Invocation {
+	return newInvocation("migrateState", map[string]string{"model_type": modelType, "limit": optionalInt(limit)})
+}
+
+// GetConfig : get the effective configuration.
+func GetConfig() *Invocation {
+	return newInvocation("getConfig", map[string]string{})
+}
+
+// Describe : describe all functions.
+func Describe() *Invocation {
+	return newInvocation("describe", map[string]string{})
+}
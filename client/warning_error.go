@@ -0,0 +1,44 @@
+/*
+ Package client provides the helpers to invoke this chaincode and to decode its responses.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package client
+
+import (
+	"fmt"
+)
+
+// WarningError : an error decoded from a WarningResult payload.
+//    the chaincode was invoked successfully but the expected result did not obtained.
+type WarningError struct {
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"message"`
+}
+
+// Error : error interface
+func (e *WarningError) Error() string {
+	return fmt.Sprintf("Warning: StatusCode=%d, Message=%s", e.StatusCode, e.Message)
+}
+
+func hasStatusCode(err error, statusCode int) bool {
+	warning, ok := err.(*WarningError)
+	return ok && warning.StatusCode == statusCode
+}
+
+// IsBadRequest : return true when the arguments were rejected by the chaincode.
+func IsBadRequest(err error) bool {
+	return hasStatusCode(err, 400)
+}
+
+// IsForbidden : return true when the invoker does not have the required role.
+func IsForbidden(err error) bool {
+	return hasStatusCode(err, 403)
+}
+
+// IsNotFound : return true when the requested object does not exist.
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, 404)
+}
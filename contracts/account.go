@@ -10,11 +10,15 @@ package contracts
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	sc "github.com/hyperledger/fabric/protos/peer"
 
 	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/registry"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/repositories"
 	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
 	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
 )
@@ -25,49 +29,191 @@ var accountLogger = shim.NewLogger("contracts/account")
 type AccountContract struct {
 }
 
-// ListAccount : return a list of all accounts.
+// Register : register the functions of AccountContract.
+func (ac *AccountContract) Register(r *registry.Registry) {
+	r.Register(&registry.Function{
+		Name:     "listAccount",
+		Handler:  ac.ListAccount,
+		Params:   params.Functions["listAccount"],
+		ReadOnly: true,
+		Result:   []*models.Account{},
+	})
+	r.Register(&registry.Function{
+		Name:     "searchAccount",
+		Handler:  ac.SearchAccount,
+		Params:   params.Functions["searchAccount"],
+		ReadOnly: true,
+		Result:   new(models.AccountPage),
+	})
+	r.Register(&registry.Function{
+		Name:     "createAccount",
+		Handler:  ac.CreateAccount,
+		Params:   params.Functions["createAccount"],
+		ReadOnly: false,
+		Result:   new(models.Account),
+	})
+	r.Register(&registry.Function{
+		Name:     "retrieveAccount",
+		Handler:  ac.RetrieveAccount,
+		Params:   params.Functions["retrieveAccount"],
+		ReadOnly: true,
+		Result:   new(models.Account),
+	})
+	r.Register(&registry.Function{
+		Name:     "updateAccountName",
+		Handler:  ac.UpdateAccountName,
+		Params:   params.Functions["updateAccountName"],
+		ReadOnly: false,
+		Result:   new(models.Account),
+	})
+	r.Register(&registry.Function{
+		Name:     "updateOverdraftLimit",
+		Handler:  ac.UpdateOverdraftLimit,
+		Params:   params.Functions["updateOverdraftLimit"],
+		ReadOnly: false,
+		Roles:    []string{utils.AdminRole},
+		Result:   new(models.Account),
+	})
+	r.Register(&registry.Function{
+		Name:     "updateAccountMetadata",
+		Handler:  ac.UpdateAccountMetadata,
+		Params:   params.Functions["updateAccountMetadata"],
+		ReadOnly: false,
+		Roles:    []string{utils.ComplianceRole},
+		Result:   new(models.Account),
+	})
+	r.Register(&registry.Function{
+		Name:     "deleteAccount",
+		Handler:  ac.DeleteAccount,
+		Params:   params.Functions["deleteAccount"],
+		ReadOnly: false,
+	})
+}
+
+// ListAccount : return a list of all accounts, or of overdrawn accounts only, optionally sorted.
 func (ac *AccountContract) ListAccount(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-	accountLogger.Infof("invoke ListAccount, args=%s\n", args)
-	if len(args) != 0 {
-		errMsg := fmt.Sprintf("Incorrect number of arguments. Expecting = no argument, Actual = %s\n", args)
-		accountLogger.Error(errMsg)
-		return shim.Error(errMsg)
+	query := new(repositories.AccountQuery)
+
+	if len(args) >= 1 {
+		switch args[0] {
+		case "":
+		case "overdrawn":
+			query.Overdrawn = true
+		default:
+			errMsg := fmt.Sprintf("Incorrect arguments. Expecting = [Optional(''|'overdrawn'), Optional('name'|'balance'[':asc'|':desc'])], Actual = %s\n", args)
+			accountLogger.Error(errMsg)
+			return shim.Error(errMsg)
+		}
 	}
 
-	query := map[string]interface{}{
-		"selector": map[string]interface{}{
-			"model_type": types.AccountModel,
-		},
+	if len(args) == 2 {
+		sort, err := utils.GetSort(args[1], repositories.AccountSortIndexes)
+		if err != nil {
+			switch e := err.(type) {
+			case *utils.WarningResult:
+				accountLogger.Warning(err.Error())
+				return shim.Success(e.JSONBytes())
+			default:
+				accountLogger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
+		}
+		query.Sort = sort
 	}
 
-	queryBytes, err := json.Marshal(query)
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	results, err := repos.Accounts.Query(query)
 	if err != nil {
 		accountLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
-	accountLogger.Infof("Query string = '%s'", string(queryBytes))
-	resultsIterator, err := APIstub.GetQueryResult(string(queryBytes))
+	jsonBytes, err := json.Marshal(results)
 	if err != nil {
 		accountLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
-	defer resultsIterator.Close()
+	return shim.Success(jsonBytes)
+}
 
-	results := make([]*models.Account, 0)
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			accountLogger.Error(err.Error())
-			return shim.Error(err.Error())
-		}
-		account := new(models.Account)
-		if err := json.Unmarshal(queryResponse.Value, account); err != nil {
+// SearchAccount : return a page of accounts whose name matches the term case-insensitively.
+func (ac *AccountContract) SearchAccount(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	term := utils.NormalizeText(args[0])
+	mode := "substring"
+	if len(args) > 1 {
+		mode = args[1]
+	}
+	limitStr, skipStr := strconv.Itoa(params.DefaultPageSize), "0"
+	if len(args) > 2 {
+		limitStr = args[2]
+	}
+	if len(args) > 3 {
+		skipStr = args[3]
+	}
+
+	if term == "" {
+		msg := "term is empty"
+		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+		accountLogger.Warning(warning.Error())
+		return shim.Success(warning.JSONBytes())
+	}
+
+	namePrefix := false
+	switch mode {
+	case "substring":
+	case "prefix":
+		namePrefix = true
+	default:
+		errMsg := fmt.Sprintf("Incorrect arguments. Expecting = ['term', Optional('substring'|'prefix'), Optional('limit'), Optional('skip')], Actual = %s\n", args)
+		accountLogger.Error(errMsg)
+		return shim.Error(errMsg)
+	}
+
+	limit, skip, err := utils.GetPagination(limitStr, skipStr)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			accountLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
 			accountLogger.Error(err.Error())
 			return shim.Error(err.Error())
 		}
-		results = append(results, account)
 	}
-	jsonBytes, err := json.Marshal(results)
+
+	sort, err := utils.GetSort("name", repositories.AccountSortIndexes)
+	if err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	accounts, err := repos.Accounts.Query(&repositories.AccountQuery{
+		NameTerm:   term,
+		NamePrefix: namePrefix,
+		Sort:       sort,
+		Limit:      limit,
+		Skip:       skip,
+	})
+	if err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	page := &models.AccountPage{
+		Accounts: accounts,
+	}
+	page.HasMore = len(page.Accounts) == limit
+	page.NextSkip = skip + len(page.Accounts)
+
+	jsonBytes, err := json.Marshal(page)
 	if err != nil {
 		accountLogger.Error(err.Error())
 		return shim.Error(err.Error())
@@ -77,32 +223,33 @@ func (ac *AccountContract) ListAccount(APIstub shim.ChaincodeStubInterface, args
 
 // CreateAccount : create a new account.
 func (ac *AccountContract) CreateAccount(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-	accountLogger.Infof("invoke CreateAccount, args=%s\n", args)
-	if len(args) != 1 {
-		errMsg := fmt.Sprintf("Incorrect number of arguments. Expecting = ['name'], Actual = %s\n", args)
-		accountLogger.Error(errMsg)
-		return shim.Error(errMsg)
-	}
 	name := args[0]
 
-	no, err := utils.GetAccountNo(APIstub)
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	no, err := repos.Accounts.NewNo()
 	if err != nil {
 		accountLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
 
-	account := models.Account{
-		ModelType: types.AccountModel,
-		No:        no,
-		Name:      name,
-		Balance:   0,
+	account := &models.Account{
+		ModelType:     types.AccountModel,
+		SchemaVersion: models.AccountSchemaVersion,
+		No:            no,
+		Name:          name,
+		Balance:       0,
+		Attributes:    map[string]string{},
 	}
-	jsonBytes, err := json.Marshal(account)
-	if err != nil {
+	if err := repos.Accounts.Put(account); err != nil {
 		accountLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
-	if err := APIstub.PutState(no, jsonBytes); err != nil {
+	jsonBytes, err := json.Marshal(account)
+	if err != nil {
 		accountLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
@@ -111,15 +258,14 @@ func (ac *AccountContract) CreateAccount(APIstub shim.ChaincodeStubInterface, ar
 
 // RetrieveAccount : return an account.
 func (ac *AccountContract) RetrieveAccount(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-	accountLogger.Infof("invoke RetrieveAccount, args=%s\n", args)
-	if len(args) != 1 {
-		errMsg := fmt.Sprintf("Incorrect number of arguments. Expecting = ['no'], Actual = %s\n", args)
-		accountLogger.Error(errMsg)
-		return shim.Error(errMsg)
-	}
 	no := args[0]
 
-	account, err := utils.GetAccount(APIstub, no)
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	account, err := repos.Accounts.Get(no)
 	if err != nil {
 		switch e := err.(type) {
 		case *utils.WarningResult:
@@ -140,16 +286,15 @@ func (ac *AccountContract) RetrieveAccount(APIstub shim.ChaincodeStubInterface,
 
 // UpdateAccountName : update the name of an account.
 func (ac *AccountContract) UpdateAccountName(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-	accountLogger.Infof("invoke UpdateAccountName, args=%s\n", args)
-	if len(args) != 2 {
-		errMsg := fmt.Sprintf("Incorrect number of arguments. Expecting = ['no', 'name'], Actual = %s\n", args)
-		accountLogger.Error(errMsg)
-		return shim.Error(errMsg)
-	}
 	no := args[0]
 	name := args[1]
 
-	account, err := utils.GetAccount(APIstub, no)
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	account, err := repos.Accounts.Get(no)
 	if err != nil {
 		switch e := err.(type) {
 		case *utils.WarningResult:
@@ -163,12 +308,135 @@ func (ac *AccountContract) UpdateAccountName(APIstub shim.ChaincodeStubInterface
 
 	account.Name = name
 
+	if err := repos.Accounts.Put(account); err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	jsonBytes, err := json.Marshal(account)
+	if err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
+
+// UpdateOverdraftLimit : update the approved overdraft limit of an account. admin only.
+func (ac *AccountContract) UpdateOverdraftLimit(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	no := args[0]
+	limitStr := args[1]
+
+	limit, err := utils.GetOverdraftLimit(limitStr)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			accountLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			accountLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+	if err := utils.CheckMaxOverdraftLimit(APIstub, limit); err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			accountLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			accountLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	account, err := repos.Accounts.Get(no)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			accountLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			accountLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	if account.Balance+limit < 0 {
+		msg := fmt.Sprintf("overdraft limit is less than the current overdrawn amount, overdraft_limit = %d, balance = %d", limit, account.Balance)
+		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+		accountLogger.Warning(warning.Error())
+		return shim.Success(warning.JSONBytes())
+	}
+
+	account.OverdraftLimit = limit
+
+	if err := repos.Accounts.Put(account); err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
 	jsonBytes, err := json.Marshal(account)
 	if err != nil {
 		accountLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
-	if err := APIstub.PutState(no, jsonBytes); err != nil {
+	return shim.Success(jsonBytes)
+}
+
+// UpdateAccountMetadata : update the customer ID, the KYC level, the account type and the attributes of an account. compliance only.
+func (ac *AccountContract) UpdateAccountMetadata(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	no := args[0]
+	metadataStr := args[1]
+
+	metadata, err := utils.GetAccountMetadata(metadataStr)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			accountLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			accountLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	account, err := repos.Accounts.Get(no)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			accountLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			accountLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	metadata.Apply(account)
+	if err := utils.CheckAttributes(account); err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			accountLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			accountLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	if err := repos.Accounts.Put(account); err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	jsonBytes, err := json.Marshal(account)
+	if err != nil {
 		accountLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
@@ -177,15 +445,14 @@ func (ac *AccountContract) UpdateAccountName(APIstub shim.ChaincodeStubInterface
 
 // DeleteAccount : delete an account.
 func (ac *AccountContract) DeleteAccount(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-	accountLogger.Infof("invoke DeleteAccount, args=%s\n", args)
-	if len(args) != 1 {
-		errMsg := fmt.Sprintf("Incorrect number of arguments. Expecting = ['no'], Actual = %s\n", args)
-		accountLogger.Error(errMsg)
-		return shim.Error(errMsg)
-	}
 	no := args[0]
 
-	_, err := utils.GetAccount(APIstub, no)
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		accountLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	_, err = repos.Accounts.Get(no)
 	if err != nil {
 		switch e := err.(type) {
 		case *utils.WarningResult:
@@ -197,7 +464,7 @@ func (ac *AccountContract) DeleteAccount(APIstub shim.ChaincodeStubInterface, ar
 		}
 	}
 
-	if err := APIstub.DelState(no); err != nil {
+	if err := repos.Accounts.Delete(no); err != nil {
 		return shim.Error(err.Error())
 	}
 	return shim.Success(nil)
@@ -0,0 +1,203 @@
+/*
+ Package contracts provides the smart contracts for Hyperledger/fabric 1.1.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package contracts
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/registry"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/repositories"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+var auditLogger = shim.NewLogger("contracts/audit")
+
+// AuditContract : a struct to verify the ledger.
+type AuditContract struct {
+}
+
+// Register : register the functions of AuditContract.
+func (adc *AuditContract) Register(r *registry.Registry) {
+	r.Register(&registry.Function{
+		Name:     "verifyLedger",
+		Handler:  adc.VerifyLedger,
+		Params:   params.Functions["verifyLedger"],
+		ReadOnly: true,
+		Roles:    []string{utils.AuditorRole},
+		Result:   new(models.LedgerReport),
+	})
+}
+
+// verifyAccount : replay the AccountState chain of the events which touch an account.
+//    the events are replayed in the order queryAccountEvents decides, which follows the chain within the same timestamp.
+func verifyAccount(events repositories.EventRepository, account *models.Account) ([]*models.Discrepancy, error) {
+	accountEvents, err := queryAccountEvents(events, account.No, "")
+	if err != nil {
+		return nil, err
+	}
+
+	discrepancies := make([]*models.Discrepancy, 0)
+	balance := 0
+	for _, event := range accountEvents {
+		for _, pair := range []struct {
+			state *models.AccountState
+			delta int
+		}{
+			{event.FromAccountState, -event.Amount},
+			{event.ToAccountState, event.Amount},
+		} {
+			state := pair.state
+			if state == nil || state.No != account.No {
+				continue
+			}
+			if state.PreviousBalance != balance {
+				discrepancies = append(discrepancies, &models.Discrepancy{
+					Kind:      models.BrokenChainDiscrepancy,
+					AccountNo: account.No,
+					EventNo:   event.No,
+					Expected:  balance,
+					Actual:    state.PreviousBalance,
+				})
+			}
+			if state.CurrentBalance-state.PreviousBalance != pair.delta {
+				discrepancies = append(discrepancies, &models.Discrepancy{
+					Kind:      models.AmountMismatchDiscrepancy,
+					AccountNo: account.No,
+					EventNo:   event.No,
+					Expected:  pair.delta,
+					Actual:    state.CurrentBalance - state.PreviousBalance,
+				})
+			}
+			balance = state.CurrentBalance
+		}
+	}
+	if balance != account.Balance {
+		discrepancies = append(discrepancies, &models.Discrepancy{
+			Kind:      models.BalanceMismatchDiscrepancy,
+			AccountNo: account.No,
+			Expected:  balance,
+			Actual:    account.Balance,
+		})
+	}
+	return discrepancies, nil
+}
+
+// sumExternalFlows : sum the amounts of the events which only credit or only debit an account,
+//    and the balances which the accounts not in accounts held when they were deleted, replayed from their events.
+func sumExternalFlows(events repositories.EventRepository, accounts []*models.Account) (int, int, int, error) {
+	allEvents, err := events.Query(new(repositories.EventQuery))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	existing := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		existing[account.No] = true
+	}
+	deletedEvents := map[string][]*models.Event{}
+	deposits, withdrawals := 0, 0
+	for _, event := range allEvents {
+		if event.FromAccountState == nil && event.ToAccountState != nil {
+			deposits += event.Amount
+		} else if event.FromAccountState != nil && event.ToAccountState == nil {
+			withdrawals += event.Amount
+		}
+		for _, state := range []*models.AccountState{event.FromAccountState, event.ToAccountState} {
+			if state != nil && !existing[state.No] {
+				deletedEvents[state.No] = append(deletedEvents[state.No], event)
+			}
+		}
+	}
+
+	deletedBalances := 0
+	for no, accountEvents := range deletedEvents {
+		sorted := sortAccountEvents(accountEvents, no)
+		deletedBalances += accountStateOf(sorted[len(sorted)-1], no).CurrentBalance
+	}
+	return deposits, withdrawals, deletedBalances, nil
+}
+
+// VerifyLedger : verify the AccountState chain of each account and the global conservation. auditor only.
+func (adc *AuditContract) VerifyLedger(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	paginated := len(args) > 0
+	limit, skip := 0, 0
+	if paginated {
+		skipStr := "0"
+		if len(args) == 2 {
+			skipStr = args[1]
+		}
+		var err error
+		limit, skip, err = utils.GetPagination(args[0], skipStr)
+		if err != nil {
+			switch e := err.(type) {
+			case *utils.WarningResult:
+				auditLogger.Warning(err.Error())
+				return shim.Success(e.JSONBytes())
+			default:
+				auditLogger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
+		}
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		auditLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	accounts, err := repos.Accounts.Query(&repositories.AccountQuery{Limit: limit, Skip: skip})
+	if err != nil {
+		auditLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	report := &models.LedgerReport{
+		AccountsChecked: len(accounts),
+		Discrepancies:   make([]*models.Discrepancy, 0),
+	}
+	totalBalances := 0
+	for _, account := range accounts {
+		discrepancies, err := verifyAccount(repos.Events, account)
+		if err != nil {
+			auditLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+		report.Discrepancies = append(report.Discrepancies, discrepancies...)
+		totalBalances += account.Balance
+	}
+
+	if paginated {
+		report.HasMore = len(accounts) == limit
+		report.NextSkip = skip + len(accounts)
+	} else {
+		deposits, withdrawals, deletedBalances, err := sumExternalFlows(repos.Events, accounts)
+		if err != nil {
+			auditLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+		report.Conservation = &models.Conservation{
+			TotalDeposits:    deposits,
+			TotalWithdrawals: withdrawals,
+			TotalBalances:    totalBalances,
+			DeletedBalances:  deletedBalances,
+			Balanced:         deposits-withdrawals == totalBalances+deletedBalances,
+		}
+	}
+
+	jsonBytes, err := json.Marshal(report)
+	if err != nil {
+		auditLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
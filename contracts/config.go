@@ -0,0 +1,161 @@
+/*
+ Package contracts provides the smart contracts for Hyperledger/fabric 1.1.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/registry"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/repositories"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+var configLogger = shim.NewLogger("contracts/config")
+
+// ConfigContract : a struct to handle the chaincode configuration.
+type ConfigContract struct {
+	registry *registry.Registry
+}
+
+// Register : register the functions of ConfigContract.
+//    the registry is also used to validate the feature toggles.
+func (cc *ConfigContract) Register(r *registry.Registry) {
+	cc.registry = r
+	r.Register(&registry.Function{
+		Name:     "getConfig",
+		Handler:  cc.GetConfig,
+		Params:   params.Functions["getConfig"],
+		ReadOnly: true,
+		Result:   new(models.Config),
+	})
+}
+
+// validateConfig : validate the settings which depend on the state and the registered functions.
+//    storedMode is the storage mode of the stored configuration, or empty when it is not stored yet.
+func (cc *ConfigContract) validateConfig(APIstub shim.ChaincodeStubInterface, config *models.Config, storedMode string) error {
+	if storedMode != "" && config.StorageMode != storedMode {
+		msg := fmt.Sprintf("storage_mode cannot be changed, storage_mode = %s, current = %s", config.StorageMode, storedMode)
+		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	if storedMode == "" && config.StorageMode != models.CouchDBStorageMode {
+		// a ledger of a version without the configuration keeps its accounts and events under plain keys
+		empty, err := isStateEmpty(APIstub)
+		if err != nil {
+			return err
+		}
+		if !empty {
+			msg := fmt.Sprintf("storage_mode can be set only on an empty state, storage_mode = %s", config.StorageMode)
+			warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+			return warning
+		}
+	}
+	for function := range config.Features {
+		if _, ok := cc.registry.Lookup(function); !ok {
+			msg := fmt.Sprintf("features has an unknown function, function = %s", function)
+			warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+			return warning
+		}
+	}
+	if !config.Enabled("getConfig") {
+		msg := "features cannot disable getConfig"
+		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	if config.FeeCollectorAccountNo != "" {
+		repos, err := repositories.ForStorageMode(APIstub, config.StorageMode)
+		if err != nil {
+			return err
+		}
+		if _, err := repos.Accounts.Get(config.FeeCollectorAccountNo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isStateEmpty : return whether no state is stored under a plain key.
+func isStateEmpty(APIstub shim.ChaincodeStubInterface) (bool, error) {
+	iter, err := APIstub.GetStateByRange("", "")
+	if err != nil {
+		return false, err
+	}
+	defer iter.Close()
+	return !iter.HasNext(), nil
+}
+
+// Init : merge the optional JSON configuration into the stored one, validate and store it.
+//    called at instantiate and upgrade. storage_mode can be set only when the configuration is stored first,
+//    and a mode other than couchdb only on an empty state.
+func (cc *ConfigContract) Init(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	if len(args) > 1 {
+		errMsg := fmt.Sprintf("Incorrect number of arguments. Expecting = [Optional('config')], Actual = %s\n", args)
+		configLogger.Error(errMsg)
+		return shim.Error(errMsg)
+	}
+
+	storedBytes, err := APIstub.GetState(utils.ConfigKey)
+	if err != nil {
+		configLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	config, err := utils.GetConfig(APIstub)
+	if err != nil {
+		configLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	storedMode := ""
+	if storedBytes != nil {
+		storedMode = config.StorageMode
+	}
+
+	if len(args) == 1 && args[0] != "" {
+		if err := utils.MergeConfig(config, args[0]); err != nil {
+			configLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+	if err := cc.validateConfig(APIstub, config, storedMode); err != nil {
+		configLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	jsonBytes, err := json.Marshal(config)
+	if err != nil {
+		configLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	if err := APIstub.PutState(utils.ConfigKey, jsonBytes); err != nil {
+		configLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	configLogger.Infof("configured chaincode, config = %s", string(jsonBytes))
+	return shim.Success(jsonBytes)
+}
+
+// GetConfig : return the effective configuration.
+func (cc *ConfigContract) GetConfig(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	config, err := utils.GetConfig(APIstub)
+	if err != nil {
+		configLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	jsonBytes, err := json.Marshal(config)
+	if err != nil {
+		configLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
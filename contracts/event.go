@@ -10,11 +10,15 @@ package contracts
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	sc "github.com/hyperledger/fabric/protos/peer"
 
 	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/registry"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/repositories"
 	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
 	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
 )
@@ -25,63 +29,124 @@ var eventLogger = shim.NewLogger("contracts/event")
 type EventContract struct {
 }
 
-// ListEvent : return a list of events.
+// Register : register the functions of EventContract.
+func (ec *EventContract) Register(r *registry.Registry) {
+	r.Register(&registry.Function{
+		Name:     "listEvent",
+		Handler:  ec.ListEvent,
+		Params:   params.Functions["listEvent"],
+		ReadOnly: true,
+		Result:   []*models.Event{},
+	})
+	r.Register(&registry.Function{
+		Name:     "deposit",
+		Handler:  ec.Deposit,
+		Params:   params.Functions["deposit"],
+		ReadOnly: false,
+		Result:   new(models.Event),
+	})
+	r.Register(&registry.Function{
+		Name:     "remit",
+		Handler:  ec.Remit,
+		Params:   params.Functions["remit"],
+		ReadOnly: false,
+		Result:   new(models.Event),
+	})
+	r.Register(&registry.Function{
+		Name:     "withdraw",
+		Handler:  ec.Withdraw,
+		Params:   params.Functions["withdraw"],
+		ReadOnly: false,
+		Result:   new(models.Event),
+	})
+	r.Register(&registry.Function{
+		Name:     "reverseEvent",
+		Handler:  ec.ReverseEvent,
+		Params:   params.Functions["reverseEvent"],
+		ReadOnly: false,
+		Roles:    []string{utils.AdminRole, utils.ComplianceRole},
+		Result:   new(models.Event),
+	})
+}
+
+// ListEvent : return a list of events filtered by a JSON filter object, optionally with a reference.
+//    the legacy positional arguments (event type and reference) are also accepted.
 func (ec *EventContract) ListEvent(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-	eventLogger.Infof("invoke ListEvent, args=%s\n", args)
-	if len(args) > 1 {
-		errMsg := fmt.Sprintf("Incorrect number of arguments. Expecting = [Optional('%s'|'%s'|'%s'), Actual = %s\n", types.DepositEvent, types.RemitEvent, types.WithdrawEvent, args)
-		eventLogger.Error(errMsg)
-		return shim.Error(errMsg)
-	}
-
-	query := map[string]interface{}{
-		"selector": map[string]interface{}{
-			"model_type": types.EventModel,
-		},
-	}
-
-	if len(args) == 1 {
-		switch args[0] {
-		case types.DepositEvent.String():
-			query["selector"].(map[string]interface{})["event_type"] = types.DepositEvent
-		case types.RemitEvent.String():
-			query["selector"].(map[string]interface{})["event_type"] = types.RemitEvent
-		case types.WithdrawEvent.String():
-			query["selector"].(map[string]interface{})["event_type"] = types.WithdrawEvent
-		default:
-			errMsg := fmt.Sprintf("Incorrect arguments. Expecting = [Optional('%s'|'%s'|'%s'), Actual = %s\n", types.DepositEvent, types.RemitEvent, types.WithdrawEvent, args)
-			eventLogger.Error(errMsg)
-			return shim.Error(errMsg)
+	filter := new(models.EventFilter)
+	if len(args) >= 1 && strings.HasPrefix(strings.TrimSpace(args[0]), "{") {
+		var err error
+		filter, err = utils.GetEventFilter(args[0])
+		if err != nil {
+			switch e := err.(type) {
+			case *utils.WarningResult:
+				eventLogger.Warning(err.Error())
+				return shim.Success(e.JSONBytes())
+			default:
+				eventLogger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
+		}
+		// the named arguments {"filter": {...}, "reference": "..."} merge the reference into the filter
+		if len(args) == 2 && args[1] != "" {
+			if filter.Reference != "" && filter.Reference != args[1] {
+				msg := fmt.Sprintf("reference conflicts with the reference of filter, reference = %s, filter = %s", args[1], args[0])
+				warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+				eventLogger.Warning(warning.Error())
+				return shim.Success(warning.JSONBytes())
+			}
+			filter.Reference = args[1]
+		}
+	} else {
+		if len(args) >= 1 {
+			switch args[0] {
+			case "":
+			case types.DepositEvent.String():
+				filter.EventTypes = []types.EventType{types.DepositEvent}
+			case types.RemitEvent.String():
+				filter.EventTypes = []types.EventType{types.RemitEvent}
+			case types.WithdrawEvent.String():
+				filter.EventTypes = []types.EventType{types.WithdrawEvent}
+			case types.InterestEvent.String():
+				filter.EventTypes = []types.EventType{types.InterestEvent}
+			case types.ReversalEvent.String():
+				filter.EventTypes = []types.EventType{types.ReversalEvent}
+			default:
+				errMsg := fmt.Sprintf("Incorrect arguments. Expecting = [Optional('filter_json')] or [Optional(''|'%s'|'%s'|'%s'|'%s'|'%s'), Optional('reference')], Actual = %s\n", types.DepositEvent, types.RemitEvent, types.WithdrawEvent, types.InterestEvent, types.ReversalEvent, args)
+				eventLogger.Error(errMsg)
+				return shim.Error(errMsg)
+			}
+		}
+		if len(args) == 2 {
+			filter.Reference = args[1]
+		}
+	}
+
+	query := &repositories.EventQuery{Filter: filter}
+	if filter.Sort != "" {
+		sort, err := utils.GetSort(filter.Sort, repositories.EventSortIndexes)
+		if err != nil {
+			switch e := err.(type) {
+			case *utils.WarningResult:
+				eventLogger.Warning(err.Error())
+				return shim.Success(e.JSONBytes())
+			default:
+				eventLogger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
 		}
+		query.Sort = sort
 	}
 
-	queryBytes, err := json.Marshal(query)
+	repos, err := repositories.New(APIstub)
 	if err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
-	eventLogger.Infof("Query string = '%s'", string(queryBytes))
-	resultsIterator, err := APIstub.GetQueryResult(string(queryBytes))
+	results, err := repos.Events.Query(query)
 	if err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
-	defer resultsIterator.Close()
-
-	results := make([]*models.Event, 0)
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			eventLogger.Error(err.Error())
-			return shim.Error(err.Error())
-		}
-		event := new(models.Event)
-		if err := json.Unmarshal(queryResponse.Value, event); err != nil {
-			eventLogger.Error(err.Error())
-			return shim.Error(err.Error())
-		}
-		results = append(results, event)
-	}
 	jsonBytes, err := json.Marshal(results)
 	if err != nil {
 		eventLogger.Error(err.Error())
@@ -92,14 +157,15 @@ func (ec *EventContract) ListEvent(APIstub shim.ChaincodeStubInterface, args []s
 
 // Deposit : deposit to an account.
 func (ec *EventContract) Deposit(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-	eventLogger.Infof("invoke Deposit, args=%s\n", args)
-	if len(args) != 2 {
-		errMsg := fmt.Sprintf("Incorrect number of arguments. Expecting = ['to_account_no', 'amount'], Actual = %s\n", args)
-		eventLogger.Error(errMsg)
-		return shim.Error(errMsg)
-	}
 	toAccountNo := args[0]
 	amountStr := args[1]
+	memo, reference := "", ""
+	if len(args) > 2 {
+		memo = args[2]
+	}
+	if len(args) > 3 {
+		reference = args[3]
+	}
 
 	amount, err := utils.GetAmount(amountStr)
 	if err != nil {
@@ -112,8 +178,23 @@ func (ec *EventContract) Deposit(APIstub shim.ChaincodeStubInterface, args []str
 			return shim.Error(err.Error())
 		}
 	}
+	if err := utils.CheckMaxAmount(APIstub, amount); err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			eventLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			eventLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
 
-	toAccount, err := utils.GetAccount(APIstub, toAccountNo)
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	toAccount, err := repos.Accounts.Get(toAccountNo)
 	if err != nil {
 		switch e := err.(type) {
 		case *utils.WarningResult:
@@ -125,7 +206,23 @@ func (ec *EventContract) Deposit(APIstub shim.ChaincodeStubInterface, args []str
 		}
 	}
 
-	eventNo, err := utils.GetEventNo(APIstub)
+	if err := utils.CheckMaxBalance(APIstub, toAccount, amount); err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			eventLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			eventLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	eventNo, err := repos.Events.NewNo()
+	if err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	timestamp, err := utils.GetTimestamp(APIstub)
 	if err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
@@ -143,19 +240,18 @@ func (ec *EventContract) Deposit(APIstub shim.ChaincodeStubInterface, args []str
 
 	event := &models.Event{
 		ModelType:        types.EventModel,
+		SchemaVersion:    models.EventSchemaVersion,
 		EventType:        types.DepositEvent,
 		No:               eventNo,
+		Timestamp:        timestamp,
 		Amount:           amount,
 		FromAccountState: nil,
 		ToAccountState:   toAccountState,
+		Memo:             memo,
+		Reference:        reference,
 	}
 
-	toAccountBytes, err := json.Marshal(toAccount)
-	if err != nil {
-		eventLogger.Error(err.Error())
-		return shim.Error(err.Error())
-	}
-	if err := APIstub.PutState(toAccount.No, toAccountBytes); err != nil {
+	if err := repos.Accounts.Put(toAccount); err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
@@ -164,7 +260,11 @@ func (ec *EventContract) Deposit(APIstub shim.ChaincodeStubInterface, args []str
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-	if err := APIstub.PutState(event.No, eventBytes); err != nil {
+	if err := repos.Events.Put(event); err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	if err := postJournal(repos.Journals, event, systemAccountOf(event.EventType)); err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
@@ -173,15 +273,16 @@ func (ec *EventContract) Deposit(APIstub shim.ChaincodeStubInterface, args []str
 
 // Remit : remit from an account to another account
 func (ec *EventContract) Remit(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-	eventLogger.Infof("invoke Remit, args=%s\n", args)
-	if len(args) != 3 {
-		errMsg := fmt.Sprintf("Incorrect number of arguments. Expecting = ['from_account_no, 'to_account_no', 'amount'], Actual = %s\n", args)
-		eventLogger.Error(errMsg)
-		return shim.Error(errMsg)
-	}
 	fromAccountNo := args[0]
 	toAccountNo := args[1]
 	amountStr := args[2]
+	memo, reference := "", ""
+	if len(args) > 3 {
+		memo = args[3]
+	}
+	if len(args) > 4 {
+		reference = args[4]
+	}
 
 	amount, err := utils.GetAmount(amountStr)
 	if err != nil {
@@ -194,8 +295,23 @@ func (ec *EventContract) Remit(APIstub shim.ChaincodeStubInterface, args []strin
 			return shim.Error(err.Error())
 		}
 	}
+	if err := utils.CheckMaxAmount(APIstub, amount); err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			eventLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			eventLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
 
-	fromAccount, err := utils.GetAccount(APIstub, fromAccountNo)
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	fromAccount, err := repos.Accounts.Get(fromAccountNo)
 	if err != nil {
 		switch e := err.(type) {
 		case *utils.WarningResult:
@@ -207,7 +323,7 @@ func (ec *EventContract) Remit(APIstub shim.ChaincodeStubInterface, args []strin
 		}
 	}
 
-	toAccount, err := utils.GetAccount(APIstub, toAccountNo)
+	toAccount, err := repos.Accounts.Get(toAccountNo)
 	if err != nil {
 		switch e := err.(type) {
 		case *utils.WarningResult:
@@ -219,14 +335,34 @@ func (ec *EventContract) Remit(APIstub shim.ChaincodeStubInterface, args []strin
 		}
 	}
 
-	if fromAccount.Balance < amount {
-		msg := fmt.Sprintf("amount is grator than the fromAccount.Balance, amount = %d, fromAccount.Balance = %d", amount, fromAccount.Balance)
-		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
-		eventLogger.Warning(warning.Error())
-		return shim.Success(warning.JSONBytes())
+	if err := utils.CheckBalance(fromAccount, amount); err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			eventLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			eventLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	if err := utils.CheckMaxBalance(APIstub, toAccount, amount); err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			eventLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			eventLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
 	}
 
-	eventNo, err := utils.GetEventNo(APIstub)
+	eventNo, err := repos.Events.NewNo()
+	if err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	timestamp, err := utils.GetTimestamp(APIstub)
 	if err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
@@ -254,38 +390,36 @@ func (ec *EventContract) Remit(APIstub shim.ChaincodeStubInterface, args []strin
 
 	event := &models.Event{
 		ModelType:        types.EventModel,
+		SchemaVersion:    models.EventSchemaVersion,
 		EventType:        types.RemitEvent,
 		No:               eventNo,
+		Timestamp:        timestamp,
 		Amount:           amount,
 		FromAccountState: fromAccountState,
 		ToAccountState:   toAccountState,
+		Memo:             memo,
+		Reference:        reference,
 	}
 
-	fromAccountBytes, err := json.Marshal(fromAccount)
-	if err != nil {
+	if err := repos.Accounts.Put(fromAccount); err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
-	if err := APIstub.PutState(fromAccount.No, fromAccountBytes); err != nil {
+
+	if err := repos.Accounts.Put(toAccount); err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
 
-	toAccountBytes, err := json.Marshal(toAccount)
+	eventBytes, err := json.Marshal(event)
 	if err != nil {
-		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
-	if err := APIstub.PutState(toAccount.No, toAccountBytes); err != nil {
+	if err := repos.Events.Put(event); err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
-
-	eventBytes, err := json.Marshal(event)
-	if err != nil {
-		return shim.Error(err.Error())
-	}
-	if err := APIstub.PutState(event.No, eventBytes); err != nil {
+	if err := postJournal(repos.Journals, event, systemAccountOf(event.EventType)); err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
@@ -294,14 +428,15 @@ func (ec *EventContract) Remit(APIstub shim.ChaincodeStubInterface, args []strin
 
 // Withdraw : withdraw from an account
 func (ec *EventContract) Withdraw(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-	eventLogger.Infof("invoke Withdraw, args=%s\n", args)
-	if len(args) != 2 {
-		errMsg := fmt.Sprintf("Incorrect number of arguments. Expecting = ['from_account_no, 'amount'], Actual = %s\n", args)
-		eventLogger.Error(errMsg)
-		return shim.Error(errMsg)
-	}
 	fromAccountNo := args[0]
 	amountStr := args[1]
+	memo, reference := "", ""
+	if len(args) > 2 {
+		memo = args[2]
+	}
+	if len(args) > 3 {
+		reference = args[3]
+	}
 
 	amount, err := utils.GetAmount(amountStr)
 	if err != nil {
@@ -314,8 +449,23 @@ func (ec *EventContract) Withdraw(APIstub shim.ChaincodeStubInterface, args []st
 			return shim.Error(err.Error())
 		}
 	}
+	if err := utils.CheckMaxAmount(APIstub, amount); err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			eventLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			eventLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
 
-	fromAccount, err := utils.GetAccount(APIstub, fromAccountNo)
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	fromAccount, err := repos.Accounts.Get(fromAccountNo)
 	if err != nil {
 		switch e := err.(type) {
 		case *utils.WarningResult:
@@ -327,14 +477,34 @@ func (ec *EventContract) Withdraw(APIstub shim.ChaincodeStubInterface, args []st
 		}
 	}
 
-	if fromAccount.Balance < amount {
-		msg := fmt.Sprintf("amount is grator than the fromAccount.Balance, amount = %d, fromAccount.Balance = %d", amount, fromAccount.Balance)
-		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
-		eventLogger.Warning(warning.Error())
-		return shim.Success(warning.JSONBytes())
+	if err := utils.CheckBalance(fromAccount, amount); err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			eventLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			eventLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
 	}
 
-	eventNo, err := utils.GetEventNo(APIstub)
+	if err := utils.CheckMaxWithdrawal(APIstub, fromAccount, amount); err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			eventLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			eventLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	eventNo, err := repos.Events.NewNo()
+	if err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	timestamp, err := utils.GetTimestamp(APIstub)
 	if err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
@@ -352,28 +522,222 @@ func (ec *EventContract) Withdraw(APIstub shim.ChaincodeStubInterface, args []st
 
 	event := &models.Event{
 		ModelType:        types.EventModel,
+		SchemaVersion:    models.EventSchemaVersion,
 		EventType:        types.WithdrawEvent,
 		No:               eventNo,
+		Timestamp:        timestamp,
 		Amount:           amount,
 		FromAccountState: fromAccountState,
 		ToAccountState:   nil,
+		Memo:             memo,
+		Reference:        reference,
+	}
+
+	if err := repos.Accounts.Put(fromAccount); err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
 	}
 
-	fromAccountBytes, err := json.Marshal(fromAccount)
+	eventBytes, err := json.Marshal(event)
 	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if err := repos.Events.Put(event); err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	if err := postJournal(repos.Journals, event, systemAccountOf(event.EventType)); err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
-	if err := APIstub.PutState(fromAccount.No, fromAccountBytes); err != nil {
+	return shim.Success(eventBytes)
+}
+
+// ReverseEvent : reverse all or a part of a past event by a compensating reversal event. admin or compliance only.
+func (ec *EventContract) ReverseEvent(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	originalNo := args[0]
+	reason := args[1]
+
+	if reason == "" {
+		msg := "reason is empty"
+		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+		eventLogger.Warning(warning.Error())
+		return shim.Success(warning.JSONBytes())
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	original, err := repos.Events.Get(originalNo)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			eventLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			eventLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	if original.EventType == types.ReversalEvent {
+		msg := fmt.Sprintf("a reversal event can not be reversed, event_no = %s", original.No)
+		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+		eventLogger.Warning(warning.Error())
+		return shim.Success(warning.JSONBytes())
+	}
+
+	remaining := original.Amount - original.ReversedAmount
+	if remaining <= 0 {
+		msg := fmt.Sprintf("this event has already been reversed, event_no = %s", original.No)
+		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+		eventLogger.Warning(warning.Error())
+		return shim.Success(warning.JSONBytes())
+	}
+
+	amount := remaining
+	if len(args) == 3 {
+		amount, err = utils.GetAmount(args[2])
+		if err != nil {
+			switch e := err.(type) {
+			case *utils.WarningResult:
+				eventLogger.Warning(err.Error())
+				return shim.Success(e.JSONBytes())
+			default:
+				eventLogger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
+		}
+		if amount == 0 || amount > remaining {
+			msg := fmt.Sprintf("amount is out of the reversible range, amount = %d, reversible amount = %d", amount, remaining)
+			warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+			eventLogger.Warning(warning.Error())
+			return shim.Success(warning.JSONBytes())
+		}
+	}
+
+	// the payee of the original event pays back, and the payer of the original event is refunded.
+	var fromAccount, toAccount *models.Account
+	if original.ToAccountState != nil {
+		fromAccount, err = repos.Accounts.Get(original.ToAccountState.No)
+		if err != nil {
+			switch e := err.(type) {
+			case *utils.WarningResult:
+				eventLogger.Warning(err.Error())
+				return shim.Success(e.JSONBytes())
+			default:
+				eventLogger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
+		}
+		if fromAccount.Balance+fromAccount.OverdraftLimit < amount {
+			msg := fmt.Sprintf("the payee does not have enough balance to reverse this event, amount = %d, balance = %d, overdraft_limit = %d, no = %s", amount, fromAccount.Balance, fromAccount.OverdraftLimit, fromAccount.No)
+			warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+			eventLogger.Warning(warning.Error())
+			return shim.Success(warning.JSONBytes())
+		}
+	}
+	if original.FromAccountState != nil {
+		toAccount, err = repos.Accounts.Get(original.FromAccountState.No)
+		if err != nil {
+			switch e := err.(type) {
+			case *utils.WarningResult:
+				eventLogger.Warning(err.Error())
+				return shim.Success(e.JSONBytes())
+			default:
+				eventLogger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
+		}
+		if err := utils.CheckMaxBalance(APIstub, toAccount, amount); err != nil {
+			switch e := err.(type) {
+			case *utils.WarningResult:
+				eventLogger.Warning(err.Error())
+				return shim.Success(e.JSONBytes())
+			default:
+				eventLogger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
+		}
+	}
+
+	eventNo, err := repos.Events.NewNo()
+	if err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	timestamp, err := utils.GetTimestamp(APIstub)
+	if err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	var fromAccountState, toAccountState *models.AccountState
+	if fromAccount != nil {
+		fromAccountPreviousBalance := fromAccount.Balance
+		fromAccount.Balance -= amount
+
+		fromAccountState = &models.AccountState{
+			No:              fromAccount.No,
+			Name:            fromAccount.Name,
+			PreviousBalance: fromAccountPreviousBalance,
+			CurrentBalance:  fromAccount.Balance,
+		}
+
+		if err := repos.Accounts.Put(fromAccount); err != nil {
+			eventLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+	if toAccount != nil {
+		toAccountPreviousBalance := toAccount.Balance
+		toAccount.Balance += amount
+
+		toAccountState = &models.AccountState{
+			No:              toAccount.No,
+			Name:            toAccount.Name,
+			PreviousBalance: toAccountPreviousBalance,
+			CurrentBalance:  toAccount.Balance,
+		}
+
+		if err := repos.Accounts.Put(toAccount); err != nil {
+			eventLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	event := &models.Event{
+		ModelType:        types.EventModel,
+		SchemaVersion:    models.EventSchemaVersion,
+		EventType:        types.ReversalEvent,
+		No:               eventNo,
+		Timestamp:        timestamp,
+		Amount:           amount,
+		FromAccountState: fromAccountState,
+		ToAccountState:   toAccountState,
+		Reference:        original.Reference,
+		OriginalEventNo:  original.No,
+		Reason:           reason,
+	}
+
+	original.ReversedAmount += amount
+	if err := repos.Events.Put(original); err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
 
 	eventBytes, err := json.Marshal(event)
 	if err != nil {
+		eventLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	if err := repos.Events.Put(event); err != nil {
+		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
-	if err := APIstub.PutState(event.No, eventBytes); err != nil {
+	if err := postJournal(repos.Journals, event, systemAccountOf(original.EventType)); err != nil {
 		eventLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
@@ -0,0 +1,243 @@
+/*
+ Package contracts provides the smart contracts for Hyperledger/fabric 1.1.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package contracts
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/registry"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/repositories"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+var exportLogger = shim.NewLogger("contracts/export")
+
+// the column order of CSV files. do not reorder them, append a new column to the end.
+var (
+	eventColumns = []string{
+		"no", "event_type", "timestamp", "amount",
+		"from_account_no", "from_account_name", "from_previous_balance", "from_current_balance",
+		"to_account_no", "to_account_name", "to_previous_balance", "to_current_balance",
+		"memo", "reference", "original_event_no", "reason",
+	}
+	accountColumns = []string{
+		"no", "name", "balance", "overdraft_limit", "customer_id", "kyc_level", "account_type",
+	}
+)
+
+// ExportContract : a struct to export state objects as RFC 4180 CSV.
+type ExportContract struct {
+}
+
+// Register : register the functions of ExportContract.
+func (exc *ExportContract) Register(r *registry.Registry) {
+	r.Register(&registry.Function{
+		Name:      "exportEvents",
+		Handler:   exc.ExportEvents,
+		Params:    params.Functions["exportEvents"],
+		ReadOnly:  true,
+		MediaType: "text/csv",
+	})
+	r.Register(&registry.Function{
+		Name:      "exportAccounts",
+		Handler:   exc.ExportAccounts,
+		Params:    params.Functions["exportAccounts"],
+		ReadOnly:  true,
+		MediaType: "text/csv",
+	})
+}
+
+func accountStateColumns(state *models.AccountState) []string {
+	if state == nil {
+		return []string{"", "", "", ""}
+	}
+	return []string{
+		state.No,
+		state.Name,
+		strconv.Itoa(state.PreviousBalance),
+		strconv.Itoa(state.CurrentBalance),
+	}
+}
+
+func writeCSV(header []string, records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.UseCRLF = true
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// getExportPagination : convert the optional limit and skip arguments and validate them
+func getExportPagination(args []string, offset int) (int, int, error) {
+	limitStr, skipStr := strconv.Itoa(params.DefaultPageSize), "0"
+	if len(args) > offset {
+		limitStr = args[offset]
+	}
+	if len(args) > offset+1 {
+		skipStr = args[offset+1]
+	}
+	return utils.GetPagination(limitStr, skipStr)
+}
+
+// ExportEvents : return a page of events filtered by a JSON filter object as CSV.
+func (exc *ExportContract) ExportEvents(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	filter := &models.EventFilter{Sort: "timestamp"}
+	if len(args) > 0 && strings.TrimSpace(args[0]) != "" {
+		var err error
+		filter, err = utils.GetEventFilter(args[0])
+		if err != nil {
+			switch e := err.(type) {
+			case *utils.WarningResult:
+				exportLogger.Warning(err.Error())
+				return shim.Success(e.JSONBytes())
+			default:
+				exportLogger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
+		}
+		if filter.Sort == "" {
+			filter.Sort = "timestamp"
+		}
+	}
+
+	limit, skip, err := getExportPagination(args, 1)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			exportLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			exportLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	eventSort, err := utils.GetSort(filter.Sort, repositories.EventSortIndexes)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			exportLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			exportLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		exportLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	events, err := repos.Events.Query(&repositories.EventQuery{
+		Filter: filter,
+		Sort:   eventSort,
+		Limit:  limit,
+		Skip:   skip,
+	})
+	if err != nil {
+		exportLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	records := make([][]string, 0, len(events))
+	for _, event := range events {
+		record := []string{event.No, event.EventType.String(), event.Timestamp, strconv.Itoa(event.Amount)}
+		record = append(record, accountStateColumns(event.FromAccountState)...)
+		record = append(record, accountStateColumns(event.ToAccountState)...)
+		record = append(record, event.Memo, event.Reference, event.OriginalEventNo, event.Reason)
+		records = append(records, record)
+	}
+
+	csvBytes, err := writeCSV(eventColumns, records)
+	if err != nil {
+		exportLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(csvBytes)
+}
+
+// ExportAccounts : return a page of all accounts, or of overdrawn accounts only, as CSV sorted by name.
+func (exc *ExportContract) ExportAccounts(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	overdrawn := false
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "":
+		case "overdrawn":
+			overdrawn = true
+		default:
+			errMsg := fmt.Sprintf("Incorrect arguments. Expecting = [Optional(''|'overdrawn'), Optional('limit'), Optional('skip')], Actual = %s\n", args)
+			exportLogger.Error(errMsg)
+			return shim.Error(errMsg)
+		}
+	}
+
+	limit, skip, err := getExportPagination(args, 1)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			exportLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			exportLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	nameSort, err := utils.GetSort("name", repositories.AccountSortIndexes)
+	if err != nil {
+		exportLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		exportLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	accounts, err := repos.Accounts.Query(&repositories.AccountQuery{
+		Overdrawn: overdrawn,
+		Sort:      nameSort,
+		Limit:     limit,
+		Skip:      skip,
+	})
+	if err != nil {
+		exportLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	records := make([][]string, 0, len(accounts))
+	for _, account := range accounts {
+		records = append(records, []string{
+			account.No, account.Name, strconv.Itoa(account.Balance), strconv.Itoa(account.OverdraftLimit),
+			account.CustomerID, strconv.Itoa(account.KYCLevel), account.AccountType,
+		})
+	}
+
+	csvBytes, err := writeCSV(accountColumns, records)
+	if err != nil {
+		exportLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(csvBytes)
+}
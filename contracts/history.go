@@ -14,40 +14,61 @@ import (
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	sc "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/registry"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/repositories"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
 )
 
 var historyLogger = shim.NewLogger("contracts/history")
 
-type historyType struct {
-	TxID      string                 `json:"tx_id"`
-	No        string                 `json:"no"`
-	State     map[string]interface{} `json:"state"`
-	Timestamp string                 `json:"timestamp"`
-	IsDelete  bool                   `json:"is_delete"`
-}
-
 // HistoryContract : a struct to query Histories
 type HistoryContract struct {
 }
 
+// Register : register the functions of HistoryContract.
+func (hc *HistoryContract) Register(r *registry.Registry) {
+	r.Register(&registry.Function{
+		Name:     "listHistory",
+		Handler:  hc.ListHistory,
+		Params:   params.Functions["listHistory"],
+		ReadOnly: true,
+		Result:   []*models.History{},
+	})
+	r.Register(&registry.Function{
+		Name:     "retrieveAccountAt",
+		Handler:  hc.RetrieveAccountAt,
+		Params:   params.Functions["retrieveAccountAt"],
+		ReadOnly: true,
+		Result:   new(models.AccountAt),
+	})
+}
+
 // ListHistory : return all histories of a state object.
 func (hc *HistoryContract) ListHistory(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
-	historyLogger.Infof("invoke ListHistory, args=%s\n", args)
-	if len(args) != 1 {
-		errMsg := fmt.Sprintf("Incorrect number of arguments. Expecting = ['no'], Actual = %s\n", args)
-		historyLogger.Error(errMsg)
-		return shim.Error(errMsg)
-	}
 	no := args[0]
 
-	resultsIterator, err := APIstub.GetHistoryForKey(no)
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		historyLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	key, err := repos.Accounts.Key(no)
+	if err != nil {
+		historyLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	resultsIterator, err := APIstub.GetHistoryForKey(key)
 	if err != nil {
 		historyLogger.Error(err.Error())
 		return shim.Error(err.Error())
 	}
 	defer resultsIterator.Close()
 
-	histories := make([]*historyType, 0)
+	histories := make([]*models.History, 0)
 	for resultsIterator.HasNext() {
 		response, err := resultsIterator.Next()
 		if err != nil {
@@ -61,7 +82,7 @@ func (hc *HistoryContract) ListHistory(APIstub shim.ChaincodeStubInterface, args
 				return shim.Error(err.Error())
 			}
 		}
-		history := &historyType{
+		history := &models.History{
 			TxID:      response.TxId,
 			No:        no,
 			State:     state,
@@ -77,3 +98,88 @@ func (hc *HistoryContract) ListHistory(APIstub shim.ChaincodeStubInterface, args
 	}
 	return shim.Success(jsonBytes)
 }
+
+// RetrieveAccountAt : return an account as it was at the timestamp.
+func (hc *HistoryContract) RetrieveAccountAt(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	no := args[0]
+	timestampStr := args[1]
+
+	timestamp, err := utils.GetTime(timestampStr)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			historyLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			historyLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+	at, err := time.Parse(utils.TimestampLayout, timestamp)
+	if err != nil {
+		historyLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		historyLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	key, err := repos.Accounts.Key(no)
+	if err != nil {
+		historyLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	resultsIterator, err := APIstub.GetHistoryForKey(key)
+	if err != nil {
+		historyLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	defer resultsIterator.Close()
+
+	// the order of histories is not guaranteed, so pick the latest version not after the timestamp.
+	var inEffect *models.AccountAt
+	var inEffectTime time.Time
+	var isDelete bool
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			historyLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+		t := time.Unix(response.Timestamp.Seconds, int64(response.Timestamp.Nanos)).UTC()
+		if t.After(at) || (inEffect != nil && t.Before(inEffectTime)) {
+			continue
+		}
+		account := new(models.Account)
+		if !response.IsDelete {
+			account, err = utils.UnmarshalAccount(response.Value)
+			if err != nil {
+				historyLogger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
+		}
+		inEffect = &models.AccountAt{
+			TxID:      response.TxId,
+			Timestamp: t.Format(utils.TimestampLayout),
+			Account:   account,
+		}
+		inEffectTime = t
+		isDelete = response.IsDelete
+	}
+
+	if inEffect == nil || isDelete || inEffect.Account.ModelType != types.AccountModel {
+		msg := fmt.Sprintf("Account did not exist at the timestamp, no = %s, timestamp = %s", no, timestampStr)
+		warning := &utils.WarningResult{StatusCode: 404, Message: msg}
+		historyLogger.Warning(warning.Error())
+		return shim.Success(warning.JSONBytes())
+	}
+
+	jsonBytes, err := json.Marshal(inEffect)
+	if err != nil {
+		historyLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
@@ -0,0 +1,320 @@
+/*
+ Package contracts provides the smart contracts for Hyperledger/fabric 1.1.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/registry"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/repositories"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+var interestLogger = shim.NewLogger("contracts/interest")
+
+// interestConfigKey : the reserved key of the interest configuration.
+const interestConfigKey = "INTEREST_CONFIG"
+
+// InterestContract : a struct to handle Interest.
+type InterestContract struct {
+}
+
+// Register : register the functions of InterestContract.
+func (ic *InterestContract) Register(r *registry.Registry) {
+	r.Register(&registry.Function{
+		Name:     "setInterestRate",
+		Handler:  ic.SetInterestRate,
+		Params:   params.Functions["setInterestRate"],
+		ReadOnly: false,
+		Roles:    []string{utils.AdminRole},
+		Result:   new(models.InterestConfig),
+	})
+	r.Register(&registry.Function{
+		Name:     "applyInterest",
+		Handler:  ic.ApplyInterest,
+		Params:   params.Functions["applyInterest"],
+		ReadOnly: false,
+		Roles:    []string{utils.AdminRole},
+		Result:   []*models.Event{},
+	})
+}
+
+func getInterestConfig(APIstub shim.ChaincodeStubInterface) (*models.InterestConfig, error) {
+	config := &models.InterestConfig{
+		ModelType: types.InterestConfigModel,
+		Rates:     map[string]int{},
+	}
+	configBytes, err := APIstub.GetState(interestConfigKey)
+	if err != nil {
+		return config, err
+	} else if configBytes == nil {
+		return config, nil
+	}
+	if err := json.Unmarshal(configBytes, config); err != nil {
+		return config, err
+	}
+	if config.Rates == nil {
+		config.Rates = map[string]int{}
+	}
+	return config, nil
+}
+
+// SetInterestRate : set the annual rate (in basis points) of an account type. admin only.
+func (ic *InterestContract) SetInterestRate(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	accountType := args[0]
+	rateStr := args[1]
+
+	rate, err := utils.GetAnnualRate(rateStr)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			interestLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			interestLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	config, err := getInterestConfig(APIstub)
+	if err != nil {
+		interestLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	config.Rates[accountType] = rate
+
+	jsonBytes, err := json.Marshal(config)
+	if err != nil {
+		interestLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	if err := APIstub.PutState(interestConfigKey, jsonBytes); err != nil {
+		interestLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
+
+// dailyBalances : return the end-of-day balances of an account for each day of [fromDate, toDate),
+//    replayed from the events which touch the account, so that a deposit just before posting earns only for its days.
+func dailyBalances(events repositories.EventRepository, no string, fromDate time.Time, toDate time.Time) ([]int, error) {
+	accountEvents, err := queryAccountEvents(events, no, toDate.Format(utils.TimestampLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]int, 0)
+	balance, i := 0, 0
+	for day := fromDate; day.Before(toDate); day = day.AddDate(0, 0, 1) {
+		endOfDay := day.AddDate(0, 0, 1).Format(utils.TimestampLayout)
+		for ; i < len(accountEvents) && accountEvents[i].Timestamp < endOfDay; i++ {
+			if state := accountStateOf(accountEvents[i], no); state != nil {
+				balance = state.CurrentBalance
+			}
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+// ApplyInterest : post the interest of the end-of-day balances of the period [from_date, to_date) to all accounts. admin only.
+func (ic *InterestContract) ApplyInterest(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	fromDateStr := args[0]
+	toDateStr := args[1]
+
+	fromDate, err := utils.GetDate(fromDateStr)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			interestLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			interestLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+	toDate, err := utils.GetDate(toDateStr)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			interestLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			interestLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+	if !fromDate.Before(toDate) {
+		msg := fmt.Sprintf("from_date is not before to_date, from_date = %s, to_date = %s", fromDateStr, toDateStr)
+		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+		interestLogger.Warning(warning.Error())
+		return shim.Success(warning.JSONBytes())
+	}
+
+	txDate, err := utils.GetTxDate(APIstub)
+	if err != nil {
+		interestLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	timestamp, err := utils.GetTimestamp(APIstub)
+	if err != nil {
+		interestLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	if toDate.After(txDate) {
+		msg := fmt.Sprintf("to_date is in the future, to_date = %s, today = %s", toDateStr, txDate.Format(utils.DateLayout))
+		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+		interestLogger.Warning(warning.Error())
+		return shim.Success(warning.JSONBytes())
+	}
+
+	config, err := getInterestConfig(APIstub)
+	if err != nil {
+		interestLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	if config.PostedUntil != "" {
+		postedUntil, err := utils.GetDate(config.PostedUntil)
+		if err != nil {
+			interestLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+		if fromDate.Before(postedUntil) {
+			msg := fmt.Sprintf("interest of this period has already been posted, from_date = %s, posted_until = %s", fromDateStr, config.PostedUntil)
+			warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+			interestLogger.Warning(warning.Error())
+			return shim.Success(warning.JSONBytes())
+		}
+	}
+	days := int(toDate.Sub(fromDate).Hours() / 24)
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		interestLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	// an account overdrawn now may have been in credit during the period, so all accounts are examined
+	accounts, err := repos.Accounts.Query(new(repositories.AccountQuery))
+	if err != nil {
+		interestLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	// every account is checked against its KYC limit before anything is written, so that the posting is all or nothing
+	type posting struct {
+		account *models.Account
+		rate    int
+		amount  int
+	}
+	postings := make([]posting, 0)
+	for _, account := range accounts {
+		rate := config.RateFor(account)
+		if rate <= 0 {
+			continue
+		}
+		balances, err := dailyBalances(repos.Events, account.No, fromDate, toDate)
+		if err != nil {
+			interestLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+		amount := utils.CalcInterest(balances, rate)
+		if amount == 0 {
+			continue
+		}
+		if err := utils.CheckMaxBalance(APIstub, account, amount); err != nil {
+			switch e := err.(type) {
+			case *utils.WarningResult:
+				interestLogger.Warning(err.Error())
+				return shim.Success(e.JSONBytes())
+			default:
+				interestLogger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
+		}
+		postings = append(postings, posting{account: account, rate: rate, amount: amount})
+	}
+
+	results := make([]*models.Event, 0)
+	for _, p := range postings {
+		account, rate, amount := p.account, p.rate, p.amount
+
+		eventNo, err := repos.Events.NewNo()
+		if err != nil {
+			interestLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+
+		previousBalance := account.Balance
+		account.Balance += amount
+
+		toAccountState := &models.AccountState{
+			No:              account.No,
+			Name:            account.Name,
+			PreviousBalance: previousBalance,
+			CurrentBalance:  account.Balance,
+		}
+
+		event := &models.Event{
+			ModelType:        types.EventModel,
+			SchemaVersion:    models.EventSchemaVersion,
+			EventType:        types.InterestEvent,
+			No:               eventNo,
+			Timestamp:        timestamp,
+			Amount:           amount,
+			FromAccountState: nil,
+			ToAccountState:   toAccountState,
+			InterestPeriod: &models.InterestPeriod{
+				From:       fromDateStr,
+				To:         toDateStr,
+				Days:       days,
+				AnnualRate: rate,
+			},
+		}
+
+		if err := repos.Accounts.Put(account); err != nil {
+			interestLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+
+		if err := repos.Events.Put(event); err != nil {
+			interestLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+		if err := postJournal(repos.Journals, event, systemAccountOf(event.EventType)); err != nil {
+			interestLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+		results = append(results, event)
+	}
+
+	config.PostedUntil = toDateStr
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		interestLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	if err := APIstub.PutState(interestConfigKey, configBytes); err != nil {
+		interestLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	jsonBytes, err := json.Marshal(results)
+	if err != nil {
+		interestLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
@@ -0,0 +1,234 @@
+/*
+ Package contracts provides the smart contracts for Hyperledger/fabric 1.1.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package contracts
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/registry"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/repositories"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+var journalLogger = shim.NewLogger("contracts/journal")
+
+// JournalContract : a struct to handle Journal.
+type JournalContract struct {
+}
+
+// Register : register the functions of JournalContract.
+func (jc *JournalContract) Register(r *registry.Registry) {
+	r.Register(&registry.Function{
+		Name:     "listJournal",
+		Handler:  jc.ListJournal,
+		Params:   params.Functions["listJournal"],
+		ReadOnly: true,
+		Result:   new(models.JournalPage),
+	})
+	r.Register(&registry.Function{
+		Name:     "retrieveJournal",
+		Handler:  jc.RetrieveJournal,
+		Params:   params.Functions["retrieveJournal"],
+		ReadOnly: true,
+		Result:   new(models.JournalEntry),
+	})
+}
+
+// systemAccountOf : return the system account which is the counterpart of a one-sided event.
+func systemAccountOf(eventType types.EventType) string {
+	switch eventType {
+	case types.DepositEvent:
+		return models.CashInAccountNo
+	case types.WithdrawEvent:
+		return models.CashOutAccountNo
+	case types.InterestEvent:
+		return models.InterestAccountNo
+	default:
+		return ""
+	}
+}
+
+// postJournal : post an event as a balanced journal entry.
+//    the debited account is FromAccountState and the credited account is ToAccountState,
+//    and systemAccountNo stands in for the missing side of a one-sided event.
+func postJournal(journals repositories.JournalRepository, event *models.Event, systemAccountNo string) error {
+	journalNo, err := journals.NewNo()
+	if err != nil {
+		return err
+	}
+
+	debitAccountNo := systemAccountNo
+	if event.FromAccountState != nil {
+		debitAccountNo = event.FromAccountState.No
+	}
+	creditAccountNo := systemAccountNo
+	if event.ToAccountState != nil {
+		creditAccountNo = event.ToAccountState.No
+	}
+
+	journal := &models.JournalEntry{
+		ModelType: types.JournalModel,
+		No:        journalNo,
+		EventNo:   event.No,
+		Timestamp: event.Timestamp,
+		Lines: []*models.JournalLine{
+			{AccountNo: debitAccountNo, Debit: event.Amount, Credit: 0},
+			{AccountNo: creditAccountNo, Debit: 0, Credit: event.Amount},
+		},
+		AccountNos: []string{debitAccountNo, creditAccountNo},
+	}
+	return journals.Put(journal)
+}
+
+// backfillOpeningJournals : post the opening balance journal entries of at most limit accounts, and return their nos.
+//    the balance of an account which its journal entries do not explain, such as the balance built up by the events
+//    stored before journals were posted, is posted against the opening balance account. so it is posted only once.
+func backfillOpeningJournals(repos *repositories.Repositories, timestamp string, limit int) ([]string, error) {
+	accounts, err := repos.Accounts.Query(new(repositories.AccountQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	nos := make([]string, 0)
+	for _, account := range accounts {
+		if len(nos) == limit {
+			break
+		}
+		journals, err := repos.Journals.Query(&repositories.JournalQuery{AccountNo: account.No})
+		if err != nil {
+			return nil, err
+		}
+		journaled := 0
+		for _, journal := range journals {
+			for _, line := range journal.Lines {
+				if line.AccountNo == account.No {
+					journaled += line.Credit - line.Debit
+				}
+			}
+		}
+		amount := account.Balance - journaled
+		if amount == 0 {
+			continue
+		}
+
+		journalNo, err := repos.Journals.NewNo()
+		if err != nil {
+			return nil, err
+		}
+		debitAccountNo, creditAccountNo := models.OpeningBalanceAccountNo, account.No
+		if amount < 0 {
+			debitAccountNo, creditAccountNo, amount = account.No, models.OpeningBalanceAccountNo, -amount
+		}
+		journal := &models.JournalEntry{
+			ModelType: types.JournalModel,
+			No:        journalNo,
+			Timestamp: timestamp,
+			Lines: []*models.JournalLine{
+				{AccountNo: debitAccountNo, Debit: amount, Credit: 0},
+				{AccountNo: creditAccountNo, Debit: 0, Credit: amount},
+			},
+			AccountNos: []string{debitAccountNo, creditAccountNo},
+		}
+		if err := repos.Journals.Put(journal); err != nil {
+			return nil, err
+		}
+		nos = append(nos, account.No)
+	}
+	return nos, nil
+}
+
+// ListJournal : return a page of journal entries, optionally of an account, sorted by timestamp.
+func (jc *JournalContract) ListJournal(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	accountNo := ""
+	if len(args) > 0 {
+		accountNo = args[0]
+	}
+	limitStr, skipStr := strconv.Itoa(params.DefaultPageSize), "0"
+	if len(args) > 1 {
+		limitStr = args[1]
+	}
+	if len(args) > 2 {
+		skipStr = args[2]
+	}
+
+	limit, skip, err := utils.GetPagination(limitStr, skipStr)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			journalLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			journalLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		journalLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	journals, err := repos.Journals.Query(&repositories.JournalQuery{
+		AccountNo: accountNo,
+		Limit:     limit,
+		Skip:      skip,
+	})
+	if err != nil {
+		journalLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	page := &models.JournalPage{
+		Journals: journals,
+	}
+	page.HasMore = len(page.Journals) == limit
+	page.NextSkip = skip + len(page.Journals)
+
+	jsonBytes, err := json.Marshal(page)
+	if err != nil {
+		journalLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
+
+// RetrieveJournal : return a journal entry.
+func (jc *JournalContract) RetrieveJournal(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	no := args[0]
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		journalLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	journal, err := repos.Journals.Get(no)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			journalLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			journalLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	jsonBytes, err := json.Marshal(journal)
+	if err != nil {
+		journalLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
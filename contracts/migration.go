@@ -0,0 +1,120 @@
+/*
+ Package contracts provides the smart contracts for Hyperledger/fabric 1.1.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/registry"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/repositories"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+var migrationLogger = shim.NewLogger("contracts/migration")
+
+// MigrationContract : a struct to rewrite stored documents to the latest schema version.
+type MigrationContract struct {
+}
+
+// Register : register the functions of MigrationContract.
+func (mc *MigrationContract) Register(r *registry.Registry) {
+	r.Register(&registry.Function{
+		Name:     "migrateState",
+		Handler:  mc.MigrateState,
+		Params:   params.Functions["migrateState"],
+		ReadOnly: false,
+		Roles:    []string{utils.AdminRole},
+		Result:   new(models.MigrationResult),
+	})
+}
+
+// MigrateState : rewrite a batch of accounts or events older than the latest schema version, or post a batch of
+//    the opening balance journal entries of the accounts whose balances the journals do not explain. admin only.
+//    the rewritten documents do not match the query any more, so invoke it repeatedly while has_more is true.
+func (mc *MigrationContract) MigrateState(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	modelTypeStr := args[0]
+	limitStr := strconv.Itoa(params.DefaultPageSize)
+	if len(args) == 2 {
+		limitStr = args[1]
+	}
+
+	var modelType types.ModelType
+	var schemaVersion int
+	switch modelTypeStr {
+	case "account":
+		modelType, schemaVersion = types.AccountModel, models.AccountSchemaVersion
+	case "event":
+		modelType, schemaVersion = types.EventModel, models.EventSchemaVersion
+	case "journal":
+		// journal entries are not versioned, and the nos of the accounts whose opening balances are posted are returned
+		modelType, schemaVersion = types.JournalModel, 0
+	default:
+		msg := fmt.Sprintf("model_type is not 'account', 'event' or 'journal', model_type = %s", modelTypeStr)
+		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+		migrationLogger.Warning(warning.Error())
+		return shim.Success(warning.JSONBytes())
+	}
+
+	limit, _, err := utils.GetPagination(limitStr, "0")
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			migrationLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			migrationLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	timestamp, err := utils.GetTimestamp(APIstub)
+	if err != nil {
+		migrationLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		migrationLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	var migrated []string
+	switch modelType {
+	case types.AccountModel:
+		migrated, err = repos.Accounts.Migrate(limit)
+	case types.EventModel:
+		migrated, err = repos.Events.Migrate(limit)
+	default:
+		migrated, err = backfillOpeningJournals(repos, timestamp, limit)
+	}
+	if err != nil {
+		migrationLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	result := &models.MigrationResult{
+		ModelType:     modelType,
+		SchemaVersion: schemaVersion,
+		Migrated:      migrated,
+	}
+	result.HasMore = len(result.Migrated) == limit
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		migrationLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
@@ -0,0 +1,304 @@
+/*
+ Package contracts provides the smart contracts for Hyperledger/fabric 1.1.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/registry"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/repositories"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+var reportLogger = shim.NewLogger("contracts/report")
+
+// ReportContract : a struct to report aggregated events.
+type ReportContract struct {
+}
+
+// Register : register the functions of ReportContract.
+func (rc *ReportContract) Register(r *registry.Registry) {
+	r.Register(&registry.Function{
+		Name:     "summarizeEvents",
+		Handler:  rc.SummarizeEvents,
+		Params:   params.Functions["summarizeEvents"],
+		ReadOnly: true,
+		Result:   new(models.EventSummary),
+	})
+	r.Register(&registry.Function{
+		Name:     "listTopAccounts",
+		Handler:  rc.ListTopAccounts,
+		Params:   params.Functions["listTopAccounts"],
+		ReadOnly: true,
+		Result:   new(models.AccountRanking),
+	})
+}
+
+// getPeriod : convert from and to to the stored timestamp layout and validate them
+func getPeriod(fromStr string, toStr string) (string, string, error) {
+	from, err := utils.GetTime(fromStr)
+	if err != nil {
+		return "", "", err
+	}
+	to, err := utils.GetTime(toStr)
+	if err != nil {
+		return "", "", err
+	}
+	if from >= to {
+		msg := fmt.Sprintf("from is not before to, from = %s, to = %s", fromStr, toStr)
+		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+		return "", "", warning
+	}
+	return from, to, nil
+}
+
+// groupKeys : return the keys of the groups which an event belongs to.
+func groupKeys(event *models.Event, groupBy string) []string {
+	switch groupBy {
+	case "event_type":
+		return []string{event.EventType.String()}
+	case "day":
+		if len(event.Timestamp) < len(utils.DateLayout) {
+			return []string{""}
+		}
+		return []string{event.Timestamp[:len(utils.DateLayout)]}
+	default:
+		keys := make([]string, 0, 2)
+		if event.FromAccountState != nil {
+			keys = append(keys, event.FromAccountState.No)
+		}
+		if event.ToAccountState != nil && (event.FromAccountState == nil || event.ToAccountState.No != event.FromAccountState.No) {
+			keys = append(keys, event.ToAccountState.No)
+		}
+		return keys
+	}
+}
+
+// SummarizeEvents : return the count and the total amount of the events in the period [from, to), grouped by event type, day or account.
+func (rc *ReportContract) SummarizeEvents(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	groupBy := args[2]
+
+	switch groupBy {
+	case "event_type", "day", "account":
+	default:
+		errMsg := fmt.Sprintf("Incorrect arguments. Expecting = ['from', 'to', 'event_type'|'day'|'account'], Actual = %s\n", args)
+		reportLogger.Error(errMsg)
+		return shim.Error(errMsg)
+	}
+
+	from, to, err := getPeriod(args[0], args[1])
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			reportLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			reportLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		reportLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	events, err := repos.Events.Query(&repositories.EventQuery{
+		Filter: &models.EventFilter{From: from, To: to},
+	})
+	if err != nil {
+		reportLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	groups := map[string]*models.SummaryGroup{}
+	for _, event := range events {
+		for _, key := range groupKeys(event, groupBy) {
+			group, ok := groups[key]
+			if !ok {
+				group = &models.SummaryGroup{Key: key}
+				groups[key] = group
+			}
+			group.Count++
+			group.TotalAmount += event.Amount
+		}
+	}
+
+	summary := &models.EventSummary{
+		From:    from,
+		To:      to,
+		GroupBy: groupBy,
+		Groups:  make([]*models.SummaryGroup, 0, len(groups)),
+	}
+	for _, group := range groups {
+		summary.Groups = append(summary.Groups, group)
+	}
+	sort.Slice(summary.Groups, func(i, j int) bool {
+		return summary.Groups[i].Key < summary.Groups[j].Key
+	})
+
+	jsonBytes, err := json.Marshal(summary)
+	if err != nil {
+		reportLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
+
+// topAccountsByBalance : return the top n accounts by balance using the balance index.
+func topAccountsByBalance(accounts repositories.AccountRepository, n int) ([]*models.AccountRank, error) {
+	balanceSort, err := utils.GetSort("balance:desc", repositories.AccountSortIndexes)
+	if err != nil {
+		return nil, err
+	}
+	topAccounts, err := accounts.Query(&repositories.AccountQuery{Sort: balanceSort, Limit: n})
+	if err != nil {
+		return nil, err
+	}
+
+	ranks := make([]*models.AccountRank, 0, n)
+	for _, account := range topAccounts {
+		ranks = append(ranks, &models.AccountRank{
+			Rank:  len(ranks) + 1,
+			No:    account.No,
+			Name:  account.Name,
+			Value: account.Balance,
+		})
+	}
+	return ranks, nil
+}
+
+// topAccountsByEvents : return the top n accounts by the events in the period [from, to).
+func topAccountsByEvents(events repositories.EventRepository, metric string, n int, from string, to string) ([]*models.AccountRank, error) {
+	periodEvents, err := events.Query(&repositories.EventQuery{
+		Filter: &models.EventFilter{From: from, To: to},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ranks := map[string]*models.AccountRank{}
+	add := func(state *models.AccountState, value int) {
+		rank, ok := ranks[state.No]
+		if !ok {
+			rank = &models.AccountRank{No: state.No, Name: state.Name}
+			ranks[state.No] = rank
+		}
+		rank.Value += value
+	}
+	for _, event := range periodEvents {
+		switch metric {
+		case "event_count":
+			if event.FromAccountState != nil {
+				add(event.FromAccountState, 1)
+			}
+			if event.ToAccountState != nil && (event.FromAccountState == nil || event.ToAccountState.No != event.FromAccountState.No) {
+				add(event.ToAccountState, 1)
+			}
+		case "sent_amount":
+			if event.FromAccountState != nil {
+				add(event.FromAccountState, event.Amount)
+			}
+		case "received_amount":
+			if event.ToAccountState != nil {
+				add(event.ToAccountState, event.Amount)
+			}
+		}
+	}
+
+	results := make([]*models.AccountRank, 0, len(ranks))
+	for _, rank := range ranks {
+		results = append(results, rank)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Value != results[j].Value {
+			return results[i].Value > results[j].Value
+		}
+		return results[i].No < results[j].No
+	})
+	if len(results) > n {
+		results = results[:n]
+	}
+	for i, rank := range results {
+		rank.Rank = i + 1
+	}
+	return results, nil
+}
+
+// ListTopAccounts : return the top n accounts by balance, or by the number of events, the sent amount or the received amount in the period [from, to).
+func (rc *ReportContract) ListTopAccounts(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	metric := args[0]
+
+	switch {
+	case metric == "balance" && len(args) == 2:
+	case (metric == "event_count" || metric == "sent_amount" || metric == "received_amount") && len(args) == 4:
+	default:
+		errMsg := fmt.Sprintf("Incorrect arguments. Expecting = ['balance', 'n'] or ['event_count'|'sent_amount'|'received_amount', 'n', 'from', 'to'], Actual = %s\n", args)
+		reportLogger.Error(errMsg)
+		return shim.Error(errMsg)
+	}
+
+	n, _, err := utils.GetPagination(args[1], "0")
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			reportLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			reportLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		reportLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	ranking := &models.AccountRanking{
+		Metric: metric,
+	}
+	if metric == "balance" {
+		ranking.Accounts, err = topAccountsByBalance(repos.Accounts, n)
+		if err != nil {
+			reportLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	} else {
+		ranking.From, ranking.To, err = getPeriod(args[2], args[3])
+		if err != nil {
+			switch e := err.(type) {
+			case *utils.WarningResult:
+				reportLogger.Warning(err.Error())
+				return shim.Success(e.JSONBytes())
+			default:
+				reportLogger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
+		}
+		ranking.Accounts, err = topAccountsByEvents(repos.Events, metric, n, ranking.From, ranking.To)
+		if err != nil {
+			reportLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	jsonBytes, err := json.Marshal(ranking)
+	if err != nil {
+		reportLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
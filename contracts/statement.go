@@ -0,0 +1,396 @@
+/*
+ Package contracts provides the smart contracts for Hyperledger/fabric 1.1.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package contracts
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/registry"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/repositories"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+var statementLogger = shim.NewLogger("contracts/statement")
+
+// StatementContract : a struct to handle Statement.
+type StatementContract struct {
+}
+
+// Register : register the functions of StatementContract.
+func (stc *StatementContract) Register(r *registry.Registry) {
+	r.Register(&registry.Function{
+		Name:     "getStatement",
+		Handler:  stc.GetStatement,
+		Params:   params.Functions["getStatement"],
+		ReadOnly: true,
+		Result:   new(models.Statement),
+	})
+	r.Register(&registry.Function{
+		Name:      "getCamt053Statement",
+		Handler:   stc.GetCamt053Statement,
+		Params:    params.Functions["getCamt053Statement"],
+		ReadOnly:  true,
+		MediaType: "application/xml",
+	})
+}
+
+// queryAccountEvents : return the events which touch an account, in the order they were applied to it.
+//    when to is not empty, only the events before to are returned.
+//    the events stored before timestamps were recorded have no timestamp, and they are always returned first.
+func queryAccountEvents(events repositories.EventRepository, no string, to string) ([]*models.Event, error) {
+	results, err := events.Query(&repositories.EventQuery{
+		Filter: &models.EventFilter{AccountNos: []string{no}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	accountEvents := make([]*models.Event, 0, len(results))
+	for _, event := range results {
+		if to == "" || event.Timestamp < to {
+			accountEvents = append(accountEvents, event)
+		}
+	}
+	return sortAccountEvents(accountEvents, no), nil
+}
+
+// accountStateOf : return the AccountState of an account in an event, or nil when the event does not touch it.
+func accountStateOf(event *models.Event, no string) *models.AccountState {
+	if event.ToAccountState != nil && event.ToAccountState.No == no {
+		return event.ToAccountState
+	}
+	if event.FromAccountState != nil && event.FromAccountState.No == no {
+		return event.FromAccountState
+	}
+	return nil
+}
+
+// sortAccountEvents : sort the events which touch an account into the order they were applied to it.
+//    the events are ordered by timestamp, which is precise only to the millisecond. the events of the same timestamp,
+//    including the events without timestamp, are ordered by following the chain of the balances of the account
+//    from zero, and by event no where the chain does not decide.
+func sortAccountEvents(events []*models.Event, no string) []*models.Event {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Timestamp != events[j].Timestamp {
+			return events[i].Timestamp < events[j].Timestamp
+		}
+		return events[i].No < events[j].No
+	})
+
+	sorted := make([]*models.Event, 0, len(events))
+	balance := 0
+	for start := 0; start < len(events); {
+		end := start
+		for end < len(events) && events[end].Timestamp == events[start].Timestamp {
+			end++
+		}
+		group := append([]*models.Event{}, events[start:end]...)
+		for len(group) > 0 {
+			next := 0
+			for i, event := range group {
+				if state := accountStateOf(event, no); state != nil && state.PreviousBalance == balance {
+					next = i
+					break
+				}
+			}
+			if state := accountStateOf(group[next], no); state != nil {
+				balance = state.CurrentBalance
+			}
+			sorted = append(sorted, group[next])
+			group = append(group[:next], group[next+1:]...)
+		}
+		start = end
+	}
+	return sorted
+}
+
+func buildStatement(repos *repositories.Repositories, account *models.Account, from string, to string) (*models.Statement, error) {
+	events, err := queryAccountEvents(repos.Events, account.No, to)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &models.Statement{
+		No:      account.No,
+		Name:    account.Name,
+		From:    from,
+		To:      to,
+		Entries: make([]*models.StatementEntry, 0),
+	}
+	for _, event := range events {
+		for _, pair := range [][2]*models.AccountState{
+			{event.FromAccountState, event.ToAccountState},
+			{event.ToAccountState, event.FromAccountState},
+		} {
+			state, other := pair[0], pair[1]
+			if state == nil || state.No != account.No {
+				continue
+			}
+			if event.Timestamp < from {
+				statement.OpeningBalance = state.CurrentBalance
+				continue
+			}
+			var counterparty *models.Counterparty
+			if other != nil {
+				counterparty = &models.Counterparty{No: other.No, Name: other.Name}
+			}
+			entry := &models.StatementEntry{
+				EventNo:        event.No,
+				EventType:      event.EventType,
+				Timestamp:      event.Timestamp,
+				Counterparty:   counterparty,
+				Amount:         state.CurrentBalance - state.PreviousBalance,
+				RunningBalance: state.CurrentBalance,
+				Memo:           event.Memo,
+				Reference:      event.Reference,
+			}
+			statement.Entries = append(statement.Entries, entry)
+		}
+	}
+	statement.ClosingBalance = statement.OpeningBalance
+	if len(statement.Entries) > 0 {
+		statement.ClosingBalance = statement.Entries[len(statement.Entries)-1].RunningBalance
+	}
+	return statement, nil
+}
+
+// GetStatement : return the statement of an account for the period [from, to).
+func (stc *StatementContract) GetStatement(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	no := args[0]
+	fromStr := args[1]
+	toStr := args[2]
+
+	from, err := utils.GetTime(fromStr)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			statementLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			statementLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+	to, err := utils.GetTime(toStr)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			statementLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			statementLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+	if from >= to {
+		msg := fmt.Sprintf("from is not before to, from = %s, to = %s", fromStr, toStr)
+		warning := &utils.WarningResult{StatusCode: 400, Message: msg}
+		statementLogger.Warning(warning.Error())
+		return shim.Success(warning.JSONBytes())
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		statementLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	account, err := repos.Accounts.Get(no)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			statementLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			statementLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	statement, err := buildStatement(repos, account, from, to)
+	if err != nil {
+		statementLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	jsonBytes, err := json.Marshal(statement)
+	if err != nil {
+		statementLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
+
+func camt053Amount(amount int, currency string) (models.Camt053Amount, string) {
+	indicator := models.Camt053Credit
+	if amount < 0 {
+		indicator = models.Camt053Debit
+		amount = -amount
+	}
+	return models.Camt053Amount{Ccy: currency, Value: strconv.Itoa(amount)}, indicator
+}
+
+func camt053Balance(code string, balance int, currency string, dateTime string) *models.Camt053Balance {
+	amount, indicator := camt053Amount(balance, currency)
+	return &models.Camt053Balance{
+		Tp:        models.Camt053BalanceType{CdOrPrtry: models.Camt053Code{Cd: code}},
+		Amt:       amount,
+		CdtDbtInd: indicator,
+		Dt:        models.Camt053DateTime{DtTm: dateTime},
+	}
+}
+
+// the max lengths of the text types of camt.053.001.02
+const (
+	camt053Max35Text  = 35
+	camt053Max70Text  = 70
+	camt053Max140Text = 140
+)
+
+// camt053Text : truncate a text to the max length of its camt.053 text type, counted in characters.
+func camt053Text(text string, maxLength int) string {
+	runes := []rune(text)
+	if len(runes) > maxLength {
+		return string(runes[:maxLength])
+	}
+	return text
+}
+
+// camt053ID : derive a message id (Max35Text) from a transaction id.
+//    the transaction id is a 64-character hex digest, so its prefix is still unique enough.
+func camt053ID(txID string) string {
+	return camt053Text(txID, camt053Max35Text)
+}
+
+// buildCamt053 : render a statement as a camt.053 document in the currency.
+func buildCamt053(statement *models.Statement, currency string, msgID string, createdAt string) *models.Camt053Document {
+	account := models.Camt053Account{
+		ID:  models.Camt053AccountID{Othr: models.Camt053Other{ID: statement.No}},
+		Ccy: currency,
+		Nm:  camt053Text(statement.Name, camt053Max70Text),
+	}
+	stmt := models.Camt053Statement{
+		ID:      msgID,
+		CreDtTm: createdAt,
+		FrToDt:  models.Camt053Period{FrDtTm: statement.From, ToDtTm: statement.To},
+		Acct:    account,
+		Bal: []*models.Camt053Balance{
+			camt053Balance(models.Camt053OpeningBooked, statement.OpeningBalance, currency, statement.From),
+			camt053Balance(models.Camt053ClosingBooked, statement.ClosingBalance, currency, statement.To),
+		},
+		Ntry: make([]*models.Camt053Entry, 0, len(statement.Entries)),
+	}
+
+	for _, entry := range statement.Entries {
+		amount, indicator := camt053Amount(entry.Amount, currency)
+		details := models.Camt053TxDetails{
+			Refs: models.Camt053Refs{EndToEndID: camt053Text(entry.Reference, camt053Max35Text), TxID: entry.EventNo},
+		}
+		if entry.Counterparty != nil {
+			party := &models.Camt053Party{Nm: camt053Text(entry.Counterparty.Name, camt053Max70Text)}
+			partyAccount := &models.Camt053Account{
+				ID: models.Camt053AccountID{Othr: models.Camt053Other{ID: entry.Counterparty.No}},
+			}
+			if indicator == models.Camt053Credit {
+				details.RltdPties = &models.Camt053RelatedParties{Dbtr: party, DbtrAcct: partyAccount}
+			} else {
+				details.RltdPties = &models.Camt053RelatedParties{Cdtr: party, CdtrAcct: partyAccount}
+			}
+		}
+		if entry.Memo != "" {
+			details.RmtInf = &models.Camt053Remittance{Ustrd: camt053Text(entry.Memo, camt053Max140Text)}
+		}
+		stmt.Ntry = append(stmt.Ntry, &models.Camt053Entry{
+			NtryRef:     entry.EventNo,
+			Amt:         amount,
+			CdtDbtInd:   indicator,
+			Sts:         models.Camt053BookedStatus,
+			BookgDt:     models.Camt053DateTime{DtTm: entry.Timestamp},
+			ValDt:       models.Camt053DateTime{DtTm: entry.Timestamp},
+			AcctSvcrRef: entry.EventNo,
+			BkTxCd: models.Camt053TxCode{
+				Prtry: models.Camt053Proprietary{Cd: entry.EventType.String(), Issr: models.Camt053ProprietaryIss},
+			},
+			NtryDtls: models.Camt053EntryDetails{TxDtls: details},
+		})
+	}
+
+	return &models.Camt053Document{
+		Xmlns: models.Camt053Namespace,
+		BkToCstmrStmt: models.Camt053BankToCustomer{
+			GrpHdr: models.Camt053GroupHeader{MsgID: msgID, CreDtTm: createdAt},
+			Stmt:   stmt,
+		},
+	}
+}
+
+// GetCamt053Statement : return the statement of an account for the period [from, to) as ISO 20022 camt.053 XML.
+func (stc *StatementContract) GetCamt053Statement(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	no := args[0]
+
+	from, to, err := getPeriod(args[1], args[2])
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			statementLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			statementLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	repos, err := repositories.New(APIstub)
+	if err != nil {
+		statementLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	account, err := repos.Accounts.Get(no)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			statementLogger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			statementLogger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	statement, err := buildStatement(repos, account, from, to)
+	if err != nil {
+		statementLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+
+	createdAt, err := utils.GetTimestamp(APIstub)
+	if err != nil {
+		statementLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	config, err := utils.GetConfig(APIstub)
+	if err != nil {
+		statementLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	document := buildCamt053(statement, config.Currency, camt053ID(APIstub.GetTxID()), createdAt)
+
+	xmlBytes, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		statementLogger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(append([]byte(xml.Header), xmlBytes...))
+}
@@ -8,12 +8,12 @@
 package main
 
 import (
-	"fmt"
-
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	sc "github.com/hyperledger/fabric/protos/peer"
 
 	"github.com/nmatsui/fabric-payment-sample-chaincode/contracts"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/registry"
 )
 
 var logger = shim.NewLogger("main")
@@ -21,46 +21,53 @@ var logger = shim.NewLogger("main")
 var accountContract = new(contracts.AccountContract)
 var eventContract = new(contracts.EventContract)
 var historyContract = new(contracts.HistoryContract)
+var interestContract = new(contracts.InterestContract)
+var statementContract = new(contracts.StatementContract)
+var auditContract = new(contracts.AuditContract)
+var journalContract = new(contracts.JournalContract)
+var reportContract = new(contracts.ReportContract)
+var exportContract = new(contracts.ExportContract)
+var migrationContract = new(contracts.MigrationContract)
+var configContract = new(contracts.ConfigContract)
+
+var functionRegistry = registry.New()
+
+func init() {
+	accountContract.Register(functionRegistry)
+	eventContract.Register(functionRegistry)
+	historyContract.Register(functionRegistry)
+	interestContract.Register(functionRegistry)
+	statementContract.Register(functionRegistry)
+	auditContract.Register(functionRegistry)
+	journalContract.Register(functionRegistry)
+	reportContract.Register(functionRegistry)
+	exportContract.Register(functionRegistry)
+	migrationContract.Register(functionRegistry)
+	configContract.Register(functionRegistry)
+	functionRegistry.Register(&registry.Function{
+		Name:     "describe",
+		Handler:  functionRegistry.Describe,
+		Params:   params.Functions["describe"],
+		ReadOnly: true,
+		Result:   new(registry.APIDescription),
+	})
+}
 
 // EntryPoint : a struct to hadle shim.Chaincode interface.
 type EntryPoint struct {
 }
 
 // Init : implementation for shim.Chaincode interface.
+//    the optional argument is the JSON configuration, like '{"Args":["init", "{\"currency\": \"JPY\"}"]}'.
 func (s *EntryPoint) Init(APIstub shim.ChaincodeStubInterface) sc.Response {
 	logger.Info("instantiated chaincode")
-	return shim.Success(nil)
+	_, args := APIstub.GetFunctionAndParameters()
+	return configContract.Init(APIstub, args)
 }
 
 // Invoke : implementation for shim.Chaincode interface.
 func (s *EntryPoint) Invoke(APIstub shim.ChaincodeStubInterface) sc.Response {
-	function, args := APIstub.GetFunctionAndParameters()
-
-	switch function {
-	case "listAccount":
-		return accountContract.ListAccount(APIstub, args)
-	case "createAccount":
-		return accountContract.CreateAccount(APIstub, args)
-	case "retrieveAccount":
-		return accountContract.RetrieveAccount(APIstub, args)
-	case "updateAccountName":
-		return accountContract.UpdateAccountName(APIstub, args)
-	case "deleteAccount":
-		return accountContract.DeleteAccount(APIstub, args)
-	case "listEvent":
-		return eventContract.ListEvent(APIstub, args)
-	case "deposit":
-		return eventContract.Deposit(APIstub, args)
-	case "remit":
-		return eventContract.Remit(APIstub, args)
-	case "withdraw":
-		return eventContract.Withdraw(APIstub, args)
-	case "listHistory":
-		return historyContract.ListHistory(APIstub, args)
-	}
-	msg := fmt.Sprintf("No such function. function = %s, args = %s", function, args)
-	logger.Error(msg)
-	return shim.Error(msg)
+	return functionRegistry.Dispatch(APIstub)
 }
 
 func main() {
@@ -11,10 +11,73 @@ import (
 	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
 )
 
+// AccountSchemaVersion : the latest schema version of Account.
+//    documents written before schema_version was introduced are version 0.
+const AccountSchemaVersion = 3
+
+// MaxKYCLevel : the highest KYC level. level 0 means the customer is not verified yet.
+const MaxKYCLevel = 3
+
 // Account: Account model
+//    AccountType selects the interest rate, and KYCLevel selects the limits in the configuration.
+//    LegacyNo marks an account whose no was generated before the check digit was introduced.
 type Account struct {
-	ModelType types.ModelType `json:"model_type"`
-	No        string          `json:"no"`
-	Name      string          `json:"name"`
-	Balance   int             `json:"balance"`
+	ModelType      types.ModelType   `json:"model_type"`
+	SchemaVersion  int               `json:"schema_version"`
+	No             string            `json:"no"`
+	Name           string            `json:"name"`
+	Balance        int               `json:"balance"`
+	OverdraftLimit int               `json:"overdraft_limit"`
+	CustomerID     string            `json:"customer_id"`
+	KYCLevel       int               `json:"kyc_level"`
+	AccountType    string            `json:"account_type"`
+	Attributes     map[string]string `json:"attributes"`
+	LegacyNo       bool              `json:"legacy_no"`
+}
+
+// AccountMetadata : Holder to update the customer and KYC metadata of an account.
+//    a nil field is left as it is, and an attribute whose value is null is removed.
+type AccountMetadata struct {
+	CustomerID  *string            `json:"customer_id,omitempty"`
+	KYCLevel    *int               `json:"kyc_level,omitempty"`
+	AccountType *string            `json:"account_type,omitempty"`
+	Attributes  map[string]*string `json:"attributes,omitempty"`
+}
+
+// Apply : apply the metadata to an account.
+func (m *AccountMetadata) Apply(account *Account) {
+	if m.CustomerID != nil {
+		account.CustomerID = *m.CustomerID
+	}
+	if m.KYCLevel != nil {
+		account.KYCLevel = *m.KYCLevel
+	}
+	if m.AccountType != nil {
+		account.AccountType = *m.AccountType
+	}
+	if account.Attributes == nil {
+		account.Attributes = map[string]string{}
+	}
+	for key, value := range m.Attributes {
+		if value == nil {
+			delete(account.Attributes, key)
+		} else {
+			account.Attributes[key] = *value
+		}
+	}
+}
+
+// Upgrade : upgrade an account of an older schema version to the latest one in memory.
+func (a *Account) Upgrade() {
+	if a.SchemaVersion >= AccountSchemaVersion {
+		return
+	}
+	// 0 -> 1: overdraft_limit of version 0 may be missing, and it is read as 0.
+	// 1 -> 2: customer_id, kyc_level and account_type are read as empty, and attributes is initialized.
+	if a.Attributes == nil {
+		a.Attributes = map[string]string{}
+	}
+	// 2 -> 3: the no was generated without a check digit, so legacy_no is set.
+	a.LegacyNo = true
+	a.SchemaVersion = AccountSchemaVersion
 }
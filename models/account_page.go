@@ -0,0 +1,16 @@
+/*
+ Package models provides the model of state objects.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package models
+
+// AccountPage: Holder to show a page of accounts.
+//    when HasMore is true, the next page starts from NextSkip.
+type AccountPage struct {
+	Accounts []*Account `json:"accounts"`
+	HasMore  bool       `json:"has_more"`
+	NextSkip int        `json:"next_skip"`
+}
@@ -0,0 +1,166 @@
+/*
+ Package models provides the model of state objects.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package models
+
+import (
+	"encoding/xml"
+)
+
+// Camt053Namespace : the XML namespace of ISO 20022 camt.053 (BankToCustomerStatementV02).
+const Camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// concrete codes of camt.053
+const (
+	Camt053Credit         = "CRDT"
+	Camt053Debit          = "DBIT"
+	Camt053OpeningBooked  = "OPBD"
+	Camt053ClosingBooked  = "CLBD"
+	Camt053BookedStatus   = "BOOK"
+	Camt053ProprietaryIss = "fabric-payment"
+)
+
+// Camt053Document : the root element of camt.053.
+type Camt053Document struct {
+	XMLName       xml.Name              `xml:"Document"`
+	Xmlns         string                `xml:"xmlns,attr"`
+	BkToCstmrStmt Camt053BankToCustomer `xml:"BkToCstmrStmt"`
+}
+
+// Camt053BankToCustomer : BankToCustomerStatement.
+type Camt053BankToCustomer struct {
+	GrpHdr Camt053GroupHeader `xml:"GrpHdr"`
+	Stmt   Camt053Statement   `xml:"Stmt"`
+}
+
+// Camt053GroupHeader : GroupHeader.
+type Camt053GroupHeader struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+// Camt053Statement : AccountStatement.
+type Camt053Statement struct {
+	ID      string            `xml:"Id"`
+	CreDtTm string            `xml:"CreDtTm"`
+	FrToDt  Camt053Period     `xml:"FrToDt"`
+	Acct    Camt053Account    `xml:"Acct"`
+	Bal     []*Camt053Balance `xml:"Bal"`
+	Ntry    []*Camt053Entry   `xml:"Ntry"`
+}
+
+// Camt053Period : DateTimePeriodDetails.
+type Camt053Period struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+// Camt053Account : CashAccount.
+type Camt053Account struct {
+	ID  Camt053AccountID `xml:"Id"`
+	Ccy string           `xml:"Ccy,omitempty"`
+	Nm  string           `xml:"Nm,omitempty"`
+}
+
+// Camt053AccountID : AccountIdentification with a proprietary identification.
+type Camt053AccountID struct {
+	Othr Camt053Other `xml:"Othr"`
+}
+
+// Camt053Other : GenericAccountIdentification.
+type Camt053Other struct {
+	ID string `xml:"Id"`
+}
+
+// Camt053Amount : ActiveOrHistoricCurrencyAndAmount.
+type Camt053Amount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Camt053DateTime : DateAndDateTimeChoice.
+type Camt053DateTime struct {
+	DtTm string `xml:"DtTm"`
+}
+
+// Camt053Balance : CashBalance.
+type Camt053Balance struct {
+	Tp        Camt053BalanceType `xml:"Tp"`
+	Amt       Camt053Amount      `xml:"Amt"`
+	CdtDbtInd string             `xml:"CdtDbtInd"`
+	Dt        Camt053DateTime    `xml:"Dt"`
+}
+
+// Camt053BalanceType : BalanceType.
+type Camt053BalanceType struct {
+	CdOrPrtry Camt053Code `xml:"CdOrPrtry"`
+}
+
+// Camt053Code : a code choice.
+type Camt053Code struct {
+	Cd string `xml:"Cd"`
+}
+
+// Camt053Entry : ReportEntry.
+type Camt053Entry struct {
+	NtryRef     string              `xml:"NtryRef"`
+	Amt         Camt053Amount       `xml:"Amt"`
+	CdtDbtInd   string              `xml:"CdtDbtInd"`
+	Sts         string              `xml:"Sts"`
+	BookgDt     Camt053DateTime     `xml:"BookgDt"`
+	ValDt       Camt053DateTime     `xml:"ValDt"`
+	AcctSvcrRef string              `xml:"AcctSvcrRef"`
+	BkTxCd      Camt053TxCode       `xml:"BkTxCd"`
+	NtryDtls    Camt053EntryDetails `xml:"NtryDtls"`
+}
+
+// Camt053TxCode : BankTransactionCodeStructure with a proprietary code.
+type Camt053TxCode struct {
+	Prtry Camt053Proprietary `xml:"Prtry"`
+}
+
+// Camt053Proprietary : ProprietaryBankTransactionCodeStructure.
+type Camt053Proprietary struct {
+	Cd   string `xml:"Cd"`
+	Issr string `xml:"Issr"`
+}
+
+// Camt053EntryDetails : EntryDetails.
+type Camt053EntryDetails struct {
+	TxDtls Camt053TxDetails `xml:"TxDtls"`
+}
+
+// Camt053TxDetails : EntryTransaction.
+type Camt053TxDetails struct {
+	Refs      Camt053Refs            `xml:"Refs"`
+	RltdPties *Camt053RelatedParties `xml:"RltdPties,omitempty"`
+	RmtInf    *Camt053Remittance     `xml:"RmtInf,omitempty"`
+}
+
+// Camt053Refs : TransactionReferences.
+type Camt053Refs struct {
+	EndToEndID string `xml:"EndToEndId,omitempty"`
+	TxID       string `xml:"TxId"`
+}
+
+// Camt053RelatedParties : TransactionParty with the counterparty of the entry.
+type Camt053RelatedParties struct {
+	Dbtr     *Camt053Party   `xml:"Dbtr,omitempty"`
+	DbtrAcct *Camt053Account `xml:"DbtrAcct,omitempty"`
+	Cdtr     *Camt053Party   `xml:"Cdtr,omitempty"`
+	CdtrAcct *Camt053Account `xml:"CdtrAcct,omitempty"`
+}
+
+// Camt053Party : PartyIdentification.
+type Camt053Party struct {
+	Nm string `xml:"Nm"`
+}
+
+// Camt053Remittance : RemittanceInformation.
+type Camt053Remittance struct {
+	Ustrd string `xml:"Ustrd"`
+}
@@ -0,0 +1,72 @@
+/*
+ Package models provides the model of state objects.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package models
+
+import (
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+)
+
+// storage modes
+//    couchdb stores accounts and events under their nos and queries them by CouchDB rich queries.
+//    composite_key stores them under composite keys and scans them by range, so it does not need CouchDB.
+const (
+	CouchDBStorageMode      = "couchdb"
+	CompositeKeyStorageMode = "composite_key"
+)
+
+// DefaultCurrency : the currency when the configuration does not specify it.
+const DefaultCurrency = "JPY"
+
+// Limits : Holder to show the limits of amounts. zero means unlimited.
+type Limits struct {
+	MaxAmount         int `json:"max_amount"`
+	MaxOverdraftLimit int `json:"max_overdraft_limit"`
+}
+
+// KYCLimits : Holder to show the limits applied to the accounts of a KYC level. zero means unlimited.
+type KYCLimits struct {
+	MaxBalance    int `json:"max_balance"`
+	MaxWithdrawal int `json:"max_withdrawal"`
+}
+
+// Config : Config model supplied at instantiate and upgrade.
+//    Features disables a function when its value is false. a function not in Features is enabled.
+//    KYCLimits is keyed by KYC level, and a level not in KYCLimits is unlimited.
+type Config struct {
+	ModelType             types.ModelType   `json:"model_type"`
+	Currency              string            `json:"currency"`
+	AdminMSPIDs           []string          `json:"admin_msp_ids"`
+	FeeCollectorAccountNo string            `json:"fee_collector_account_no"`
+	Limits                Limits            `json:"limits"`
+	KYCLimits             map[int]KYCLimits `json:"kyc_limits"`
+	StorageMode           string            `json:"storage_mode"`
+	Features              map[string]bool   `json:"features"`
+}
+
+// NewConfig : return the default configuration.
+func NewConfig() *Config {
+	return &Config{
+		ModelType:   types.ConfigModel,
+		Currency:    DefaultCurrency,
+		AdminMSPIDs: make([]string, 0),
+		KYCLimits:   map[int]KYCLimits{},
+		StorageMode: CouchDBStorageMode,
+		Features:    map[string]bool{},
+	}
+}
+
+// Enabled : return whether a function is enabled.
+func (c *Config) Enabled(function string) bool {
+	enabled, ok := c.Features[function]
+	return !ok || enabled
+}
+
+// KYCLimitsFor : return the limits applied to an account by its KYC level.
+func (c *Config) KYCLimitsFor(account *Account) KYCLimits {
+	return c.KYCLimits[account.KYCLevel]
+}
@@ -19,12 +19,39 @@ type AccountState struct {
 	CurrentBalance  int    `json:"current_balance"`
 }
 
-// Event: Event model to show deposit, remit or withdraw event.
+// EventSchemaVersion : the latest schema version of Event.
+//    documents written before schema_version was introduced are version 0.
+const EventSchemaVersion = 2
+
+// Event: Event model to show deposit, remit, withdraw, interest or reversal event.
 type Event struct {
 	ModelType        types.ModelType `json:"model_type"`
+	SchemaVersion    int             `json:"schema_version"`
 	EventType        types.EventType `json:"event_type"`
 	No               string          `json:"no"`
+	Timestamp        string          `json:"timestamp"`
 	Amount           int             `json:"amount"`
 	FromAccountState *AccountState   `json:"from_account"`
 	ToAccountState   *AccountState   `json:"to_account"`
+	Memo             string          `json:"memo"`
+	Reference        string          `json:"reference"`
+	InterestPeriod   *InterestPeriod `json:"interest_period,omitempty"`
+	ReversedAmount   int             `json:"reversed_amount,omitempty"`
+	OriginalEventNo  string          `json:"original_event_no,omitempty"`
+	Reason           string          `json:"reason,omitempty"`
+}
+
+// Upgrade : upgrade an event of an older schema version to the latest one in memory.
+func (e *Event) Upgrade() {
+	if e.SchemaVersion >= EventSchemaVersion {
+		return
+	}
+	// 0 -> 1: timestamp, memo and reference of version 0 may be missing, and they are read as empty.
+	// 1 -> 2: a missing timestamp is backfilled from the history of the key by migrateState,
+	//    so an event without timestamp stays at version 1 until it is migrated.
+	if e.Timestamp == "" {
+		e.SchemaVersion = 1
+		return
+	}
+	e.SchemaVersion = EventSchemaVersion
 }
@@ -0,0 +1,28 @@
+/*
+ Package models provides the model of state objects.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package models
+
+import (
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+)
+
+// EventFilter: Holder to show the conditions to filter events.
+//    every condition is optional, and the conditions are combined with AND.
+//    AccountNos matches events whose from_account or to_account is one of them,
+//    and the time range is [From, To).
+//    Sort is 'field', 'field:asc' or 'field:desc'.
+type EventFilter struct {
+	EventTypes []types.EventType `json:"event_types,omitempty"`
+	AccountNos []string          `json:"account_nos,omitempty"`
+	AmountMin  *int              `json:"amount_min,omitempty"`
+	AmountMax  *int              `json:"amount_max,omitempty"`
+	From       string            `json:"from,omitempty"`
+	To         string            `json:"to,omitempty"`
+	Reference  string            `json:"reference,omitempty"`
+	Sort       string            `json:"sort,omitempty"`
+}
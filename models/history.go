@@ -0,0 +1,24 @@
+/*
+ Package models provides the model of state objects.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package models
+
+// History : Holder to show a version of a state object.
+type History struct {
+	TxID      string                 `json:"tx_id"`
+	No        string                 `json:"no"`
+	State     map[string]interface{} `json:"state"`
+	Timestamp string                 `json:"timestamp"`
+	IsDelete  bool                   `json:"is_delete"`
+}
+
+// AccountAt : Holder to show an account as it was at a timestamp.
+type AccountAt struct {
+	TxID      string   `json:"tx_id"`
+	Timestamp string   `json:"timestamp"`
+	Account   *Account `json:"account"`
+}
@@ -0,0 +1,39 @@
+/*
+ Package models provides the model of state objects.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package models
+
+import (
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+)
+
+// DefaultRateKey : the key of the annual rate applied to accounts which have no specific rate.
+const DefaultRateKey = "default"
+
+// InterestConfig: Interest configuration model to hold annual rates and the posted period.
+type InterestConfig struct {
+	ModelType   types.ModelType `json:"model_type"`
+	Rates       map[string]int  `json:"rates"`
+	PostedUntil string          `json:"posted_until"`
+}
+
+// RateFor : return the annual rate (in basis points) applied to an account.
+//    the rate of the account type is applied if it is set, otherwise the default rate.
+func (c *InterestConfig) RateFor(account *Account) int {
+	if rate, ok := c.Rates[account.AccountType]; ok && account.AccountType != "" {
+		return rate
+	}
+	return c.Rates[DefaultRateKey]
+}
+
+// InterestPeriod: Holder to show the period and rate of an interest event.
+type InterestPeriod struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Days       int    `json:"days"`
+	AnnualRate int    `json:"annual_rate"`
+}
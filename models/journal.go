@@ -0,0 +1,47 @@
+/*
+ Package models provides the model of state objects.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package models
+
+import (
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+)
+
+// system accounts to post the counter lines of deposit, withdraw, interest and opening balance.
+const (
+	CashInAccountNo         = "SYSTEM_CASH_IN"
+	CashOutAccountNo        = "SYSTEM_CASH_OUT"
+	InterestAccountNo       = "SYSTEM_INTEREST"
+	OpeningBalanceAccountNo = "SYSTEM_OPENING_BALANCE"
+)
+
+// JournalLine: Holder to show a debit or credit line of a journal entry.
+type JournalLine struct {
+	AccountNo string `json:"account_no"`
+	Debit     int    `json:"debit"`
+	Credit    int    `json:"credit"`
+}
+
+// JournalEntry: Journal model to post an event as a balanced double entry.
+//    AccountNos holds the account numbers of Lines to query journals by account.
+//    EventNo is empty for an opening balance posted by the migration.
+type JournalEntry struct {
+	ModelType  types.ModelType `json:"model_type"`
+	No         string          `json:"no"`
+	EventNo    string          `json:"event_no"`
+	Timestamp  string          `json:"timestamp"`
+	Lines      []*JournalLine  `json:"lines"`
+	AccountNos []string        `json:"account_nos"`
+}
+
+// JournalPage: Holder to show a page of journal entries.
+//    when HasMore is true, the next page starts from NextSkip.
+type JournalPage struct {
+	Journals []*JournalEntry `json:"journals"`
+	HasMore  bool            `json:"has_more"`
+	NextSkip int             `json:"next_skip"`
+}
@@ -0,0 +1,46 @@
+/*
+ Package models provides the model of state objects.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package models
+
+// concrete discrepancy kinds
+const (
+	BrokenChainDiscrepancy     = "broken_chain"
+	AmountMismatchDiscrepancy  = "amount_mismatch"
+	BalanceMismatchDiscrepancy = "balance_mismatch"
+)
+
+// Discrepancy: Holder to show an inconsistency found in the ledger.
+type Discrepancy struct {
+	Kind      string `json:"kind"`
+	AccountNo string `json:"account_no"`
+	EventNo   string `json:"event_no"`
+	Expected  int    `json:"expected"`
+	Actual    int    `json:"actual"`
+}
+
+// Conservation: Holder to show the global conservation of value.
+//    TotalDeposits sums the events which only credit an account (deposit, interest and reversed withdraw),
+//    TotalWithdrawals sums the events which only debit an account (withdraw and reversed deposit or interest).
+//    DeletedBalances sums the balances which the deleted accounts held when they were deleted.
+type Conservation struct {
+	TotalDeposits    int  `json:"total_deposits"`
+	TotalWithdrawals int  `json:"total_withdrawals"`
+	TotalBalances    int  `json:"total_balances"`
+	DeletedBalances  int  `json:"deleted_balances"`
+	Balanced         bool `json:"balanced"`
+}
+
+// LedgerReport: Holder to show the result of the ledger verification.
+//    Conservation is nil in the paginated mode because it needs all accounts.
+type LedgerReport struct {
+	AccountsChecked int            `json:"accounts_checked"`
+	Discrepancies   []*Discrepancy `json:"discrepancies"`
+	Conservation    *Conservation  `json:"conservation"`
+	HasMore         bool           `json:"has_more"`
+	NextSkip        int            `json:"next_skip"`
+}
@@ -0,0 +1,21 @@
+/*
+ Package models provides the model of state objects.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package models
+
+import (
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+)
+
+// MigrationResult : Holder to show a batch of migration.
+//    when HasMore is true, invoke the migration again until it becomes false.
+type MigrationResult struct {
+	ModelType     types.ModelType `json:"model_type"`
+	SchemaVersion int             `json:"schema_version"`
+	Migrated      []string        `json:"migrated"`
+	HasMore       bool            `json:"has_more"`
+}
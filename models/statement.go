@@ -0,0 +1,42 @@
+/*
+ Package models provides the model of state objects.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package models
+
+import (
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+)
+
+// Counterparty: Holder to show the other account of a movement.
+type Counterparty struct {
+	No   string `json:"no"`
+	Name string `json:"name"`
+}
+
+// StatementEntry: Holder to show a movement of an account.
+//    Amount is positive when the account is credited and negative when debited.
+type StatementEntry struct {
+	EventNo        string          `json:"event_no"`
+	EventType      types.EventType `json:"event_type"`
+	Timestamp      string          `json:"timestamp"`
+	Counterparty   *Counterparty   `json:"counterparty"`
+	Amount         int             `json:"amount"`
+	RunningBalance int             `json:"running_balance"`
+	Memo           string          `json:"memo"`
+	Reference      string          `json:"reference"`
+}
+
+// Statement: Holder to show the movements of an account for a period [From, To).
+type Statement struct {
+	No             string            `json:"no"`
+	Name           string            `json:"name"`
+	From           string            `json:"from"`
+	To             string            `json:"to"`
+	OpeningBalance int               `json:"opening_balance"`
+	ClosingBalance int               `json:"closing_balance"`
+	Entries        []*StatementEntry `json:"entries"`
+}
@@ -0,0 +1,40 @@
+/*
+ Package models provides the model of state objects.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package models
+
+// SummaryGroup: Holder to show the count and the total amount of a group of events.
+type SummaryGroup struct {
+	Key         string `json:"key"`
+	Count       int    `json:"count"`
+	TotalAmount int    `json:"total_amount"`
+}
+
+// EventSummary: Holder to show the events of a period [From, To) grouped by GroupBy.
+type EventSummary struct {
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	GroupBy string          `json:"group_by"`
+	Groups  []*SummaryGroup `json:"groups"`
+}
+
+// AccountRank: Holder to show an account and its value of a ranking metric.
+type AccountRank struct {
+	Rank  int    `json:"rank"`
+	No    string `json:"no"`
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+// AccountRanking: Holder to show the top accounts by Metric.
+//    From and To are empty when the metric does not depend on a period.
+type AccountRanking struct {
+	Metric   string         `json:"metric"`
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Accounts []*AccountRank `json:"accounts"`
+}
@@ -0,0 +1,148 @@
+/*
+ Package params provides the argument specs of chaincode functions.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package params
+
+import (
+	"strconv"
+)
+
+// page sizes
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// defaultLimit : the default page size as an argument.
+var defaultLimit = strconv.Itoa(DefaultPageSize)
+
+// Functions : the positional argument specs of all chaincode functions.
+var Functions = map[string][]Param{
+	"listAccount": {
+		{Name: "filter", Kind: StringKind, Optional: true},
+		{Name: "sort", Kind: StringKind, Optional: true},
+	},
+	"searchAccount": {
+		{Name: "term", Kind: StringKind},
+		{Name: "mode", Kind: StringKind, Optional: true, Default: "substring"},
+		{Name: "limit", Kind: IntegerKind, Optional: true, Default: defaultLimit},
+		{Name: "skip", Kind: IntegerKind, Optional: true, Default: "0"},
+	},
+	"createAccount": {
+		{Name: "name", Kind: StringKind, Format: NameFormat},
+	},
+	"retrieveAccount": {
+		{Name: "no", Kind: StringKind, Format: AccountNoFormat},
+	},
+	"updateAccountName": {
+		{Name: "no", Kind: StringKind, Format: AccountNoFormat},
+		{Name: "name", Kind: StringKind, Format: NameFormat},
+	},
+	"updateOverdraftLimit": {
+		{Name: "no", Kind: StringKind, Format: AccountNoFormat},
+		{Name: "overdraft_limit", Kind: IntegerKind},
+	},
+	"updateAccountMetadata": {
+		{Name: "no", Kind: StringKind, Format: AccountNoFormat},
+		{Name: "metadata", Kind: ObjectKind},
+	},
+	"deleteAccount": {
+		{Name: "no", Kind: StringKind, Format: AccountNoFormat},
+	},
+	"listEvent": {
+		{Name: "filter", Kind: ObjectKind, Optional: true},
+		{Name: "reference", Kind: StringKind, Optional: true, Format: ReferenceFormat},
+	},
+	"deposit": {
+		{Name: "to_account_no", Kind: StringKind, Format: CheckedAccountNoFormat},
+		{Name: "amount", Kind: IntegerKind},
+		{Name: "memo", Kind: StringKind, Optional: true, Format: MemoFormat},
+		{Name: "reference", Kind: StringKind, Optional: true, Format: ReferenceFormat},
+	},
+	"remit": {
+		{Name: "from_account_no", Kind: StringKind, Format: AccountNoFormat},
+		{Name: "to_account_no", Kind: StringKind, Format: CheckedAccountNoFormat},
+		{Name: "amount", Kind: IntegerKind},
+		{Name: "memo", Kind: StringKind, Optional: true, Format: MemoFormat},
+		{Name: "reference", Kind: StringKind, Optional: true, Format: ReferenceFormat},
+	},
+	"withdraw": {
+		{Name: "from_account_no", Kind: StringKind, Format: AccountNoFormat},
+		{Name: "amount", Kind: IntegerKind},
+		{Name: "memo", Kind: StringKind, Optional: true, Format: MemoFormat},
+		{Name: "reference", Kind: StringKind, Optional: true, Format: ReferenceFormat},
+	},
+	"reverseEvent": {
+		{Name: "event_no", Kind: StringKind, Format: EventNoFormat},
+		{Name: "reason", Kind: StringKind, Format: MemoFormat},
+		{Name: "amount", Kind: IntegerKind, Optional: true},
+	},
+	"listHistory": {
+		{Name: "no", Kind: StringKind, Format: AccountNoFormat},
+	},
+	"retrieveAccountAt": {
+		{Name: "no", Kind: StringKind, Format: AccountNoFormat},
+		{Name: "timestamp", Kind: StringKind},
+	},
+	"setInterestRate": {
+		{Name: "account_type", Kind: StringKind},
+		{Name: "annual_rate", Kind: IntegerKind},
+	},
+	"applyInterest": {
+		{Name: "from_date", Kind: StringKind},
+		{Name: "to_date", Kind: StringKind},
+	},
+	"getStatement": {
+		{Name: "no", Kind: StringKind, Format: AccountNoFormat},
+		{Name: "from", Kind: StringKind},
+		{Name: "to", Kind: StringKind},
+	},
+	"getCamt053Statement": {
+		{Name: "no", Kind: StringKind, Format: AccountNoFormat},
+		{Name: "from", Kind: StringKind},
+		{Name: "to", Kind: StringKind},
+	},
+	"verifyLedger": {
+		{Name: "limit", Kind: IntegerKind, Optional: true, Default: defaultLimit},
+		{Name: "skip", Kind: IntegerKind, Optional: true, Default: "0"},
+	},
+	"listJournal": {
+		{Name: "account_no", Kind: StringKind, Optional: true},
+		{Name: "limit", Kind: IntegerKind, Optional: true, Default: defaultLimit},
+		{Name: "skip", Kind: IntegerKind, Optional: true, Default: "0"},
+	},
+	"retrieveJournal": {
+		{Name: "no", Kind: StringKind},
+	},
+	"summarizeEvents": {
+		{Name: "from", Kind: StringKind},
+		{Name: "to", Kind: StringKind},
+		{Name: "group_by", Kind: StringKind},
+	},
+	"listTopAccounts": {
+		{Name: "metric", Kind: StringKind},
+		{Name: "n", Kind: IntegerKind},
+		{Name: "from", Kind: StringKind, Optional: true},
+		{Name: "to", Kind: StringKind, Optional: true},
+	},
+	"exportEvents": {
+		{Name: "filter", Kind: ObjectKind, Optional: true},
+		{Name: "limit", Kind: IntegerKind, Optional: true, Default: defaultLimit},
+		{Name: "skip", Kind: IntegerKind, Optional: true, Default: "0"},
+	},
+	"exportAccounts": {
+		{Name: "filter", Kind: StringKind, Optional: true},
+		{Name: "limit", Kind: IntegerKind, Optional: true, Default: defaultLimit},
+		{Name: "skip", Kind: IntegerKind, Optional: true, Default: "0"},
+	},
+	"migrateState": {
+		{Name: "model_type", Kind: StringKind},
+		{Name: "limit", Kind: IntegerKind, Optional: true, Default: defaultLimit},
+	},
+	"getConfig": {},
+	"describe":  {},
+}
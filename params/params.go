@@ -0,0 +1,168 @@
+/*
+ Package params provides the argument specs of chaincode functions.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package params
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Kind : the JSON type of a named parameter.
+type Kind string
+
+// concrete Kind
+const (
+	StringKind  Kind = "string"
+	IntegerKind Kind = "integer"
+	ObjectKind  Kind = "object"
+)
+
+// Format : the format which a string parameter must satisfy.
+type Format string
+
+// concrete Format
+const (
+	AccountNoFormat        Format = "account_no"
+	CheckedAccountNoFormat Format = "checked_account_no"
+	EventNoFormat          Format = "event_no"
+	NameFormat             Format = "name"
+	MemoFormat             Format = "memo"
+	ReferenceFormat        Format = "reference"
+)
+
+// Param : the spec of a positional argument.
+//    Default fills an omitted optional argument which is followed by a given one.
+//    Format is validated by the dispatcher before the handler is called.
+type Param struct {
+	Name     string `json:"name"`
+	Kind     Kind   `json:"kind"`
+	Optional bool   `json:"optional"`
+	Default  string `json:"default,omitempty"`
+	Format   Format `json:"format,omitempty"`
+}
+
+// Error : an error to show that the named arguments do not match the spec.
+type Error struct {
+	Message string
+}
+
+// Error : error interface
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Required : return the number of required arguments.
+func Required(specs []Param) int {
+	n := 0
+	for _, spec := range specs {
+		if !spec.Optional {
+			n++
+		}
+	}
+	return n
+}
+
+// isNamed : judge whether args is a single JSON object of named arguments.
+//    when the first argument itself is a JSON object (e.g. a filter), the object is judged
+//    as named arguments only if all of its keys are parameter names.
+func isNamed(specs []Param, args []string) (map[string]json.RawMessage, bool) {
+	if len(specs) == 0 || len(args) != 1 || !strings.HasPrefix(strings.TrimSpace(args[0]), "{") {
+		return nil, false
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(args[0]), &object); err != nil {
+		return nil, false
+	}
+	if specs[0].Kind == ObjectKind {
+		for key := range object {
+			if !hasParam(specs, key) {
+				return nil, false
+			}
+		}
+	}
+	return object, true
+}
+
+func hasParam(specs []Param, name string) bool {
+	for _, spec := range specs {
+		if spec.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func convert(spec Param, raw json.RawMessage) (string, error) {
+	switch spec.Kind {
+	case IntegerKind:
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		var number json.Number
+		if strings.HasPrefix(strings.TrimSpace(string(raw)), "\"") || decoder.Decode(&number) != nil {
+			return "", &Error{Message: fmt.Sprintf("%s is not an integer, value = %s", spec.Name, raw)}
+		}
+		if _, err := number.Int64(); err != nil {
+			return "", &Error{Message: fmt.Sprintf("%s is not an integer, value = %s", spec.Name, raw)}
+		}
+		return number.String(), nil
+	case ObjectKind:
+		var object map[string]interface{}
+		if err := json.Unmarshal(raw, &object); err == nil && object != nil {
+			return string(raw), nil
+		}
+		fallthrough
+	default:
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return "", &Error{Message: fmt.Sprintf("%s is not a string, value = %s", spec.Name, raw)}
+		}
+		return value, nil
+	}
+}
+
+// Parse : convert a single JSON object of named arguments to positional arguments by the specs of the function.
+//    positional arguments are returned as they are.
+func Parse(function string, specs []Param, args []string) ([]string, error) {
+	object, named := isNamed(specs, args)
+	if !named {
+		return args, nil
+	}
+
+	for key := range object {
+		if !hasParam(specs, key) {
+			return nil, &Error{Message: fmt.Sprintf("unknown parameter, function = %s, parameter = %s", function, key)}
+		}
+	}
+
+	values := make([]string, len(specs))
+	last := -1
+	for i, spec := range specs {
+		raw, ok := object[spec.Name]
+		if !ok || string(raw) == "null" {
+			if !spec.Optional {
+				return nil, &Error{Message: fmt.Sprintf("required parameter is missing, function = %s, parameter = %s", function, spec.Name)}
+			}
+			values[i] = spec.Default
+			continue
+		}
+		value, err := convert(spec, raw)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+		last = i
+	}
+
+	n := Required(specs)
+	if last+1 > n {
+		n = last + 1
+	}
+	return values[:n], nil
+}
@@ -0,0 +1,76 @@
+/*
+ Package registry provides the function registry and the dispatcher of this chaincode.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package registry
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/schema"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+// FunctionDescription : the description of a callable function.
+//    Params keeps the positional order of the arguments, and Arguments is the schema of the named arguments.
+type FunctionDescription struct {
+	Name      string         `json:"name"`
+	ReadOnly  bool           `json:"read_only"`
+	Roles     []string       `json:"roles"`
+	Params    []params.Param `json:"params"`
+	Arguments *schema.Schema `json:"arguments"`
+	Result    *schema.Schema `json:"result"`
+}
+
+// APIDescription : the description of all callable functions.
+//    any function may return Warning instead of its Result.
+type APIDescription struct {
+	Schema    string                 `json:"$schema"`
+	Functions []*FunctionDescription `json:"functions"`
+	Warning   *schema.Schema         `json:"warning"`
+}
+
+// Describe : return the description of all registered functions.
+func (r *Registry) Describe(APIstub shim.ChaincodeStubInterface, args []string) sc.Response {
+	description := &APIDescription{
+		Schema:    schema.Draft,
+		Functions: make([]*FunctionDescription, 0, len(r.names)),
+		Warning:   schema.Of(new(utils.WarningResult)),
+	}
+	for _, function := range r.Functions() {
+		roles := function.Roles
+		if roles == nil {
+			roles = make([]string, 0)
+		}
+		specs := function.Params
+		if specs == nil {
+			specs = make([]params.Param, 0)
+		}
+		result := schema.Of(function.Result)
+		if function.MediaType != "" {
+			result = schema.Text(function.MediaType)
+		}
+		description.Functions = append(description.Functions, &FunctionDescription{
+			Name:      function.Name,
+			ReadOnly:  function.ReadOnly,
+			Roles:     roles,
+			Params:    specs,
+			Arguments: schema.Arguments(specs),
+			Result:    result,
+		})
+	}
+
+	jsonBytes, err := json.Marshal(description)
+	if err != nil {
+		logger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	return shim.Success(jsonBytes)
+}
@@ -0,0 +1,31 @@
+/*
+ Package registry provides the function registry and the dispatcher of this chaincode.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package registry
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+var errReadOnly = errors.New("this function is read-only, writing the state is not allowed")
+
+// readOnlyStub : a ChaincodeStubInterface which refuses to write the state.
+type readOnlyStub struct {
+	shim.ChaincodeStubInterface
+}
+
+// PutState : refuse to write the state.
+func (s *readOnlyStub) PutState(key string, value []byte) error {
+	return errReadOnly
+}
+
+// DelState : refuse to delete the state.
+func (s *readOnlyStub) DelState(key string) error {
+	return errReadOnly
+}
@@ -0,0 +1,157 @@
+/*
+ Package registry provides the function registry and the dispatcher of this chaincode.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+var logger = shim.NewLogger("registry")
+
+// Handler : a function to handle an invocation.
+type Handler func(APIstub shim.ChaincodeStubInterface, args []string) sc.Response
+
+// Function : a registered handler and its metadata.
+//    Roles are the roles one of which the invoker must have. empty Roles means anyone can invoke.
+//    Result is a value of the type which the handler returns as JSON, and is used only to describe the function.
+//    MediaType is set instead of Result when the handler returns a non-JSON payload.
+type Function struct {
+	Name      string
+	Handler   Handler
+	Params    []params.Param
+	ReadOnly  bool
+	Roles     []string
+	Result    interface{}
+	MediaType string
+}
+
+// Registry : a struct to hold the registered functions.
+type Registry struct {
+	functions map[string]*Function
+	names     []string
+}
+
+// New : return an empty Registry.
+func New() *Registry {
+	return &Registry{
+		functions: map[string]*Function{},
+		names:     make([]string, 0),
+	}
+}
+
+// Register : register a function. registering the same name twice is a programming error.
+func (r *Registry) Register(function *Function) {
+	if _, ok := r.functions[function.Name]; ok {
+		panic(fmt.Sprintf("function is already registered, function = %s", function.Name))
+	}
+	r.functions[function.Name] = function
+	r.names = append(r.names, function.Name)
+}
+
+// Lookup : return a registered function.
+func (r *Registry) Lookup(name string) (*Function, bool) {
+	function, ok := r.functions[name]
+	return function, ok
+}
+
+// Functions : return all registered functions in the order of registration.
+func (r *Registry) Functions() []*Function {
+	functions := make([]*Function, 0, len(r.names))
+	for _, name := range r.names {
+		functions = append(functions, r.functions[name])
+	}
+	return functions
+}
+
+// expecting : render the params like ['no', 'name', Optional('memo')].
+func expecting(specs []params.Param) string {
+	names := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		if spec.Optional {
+			names = append(names, fmt.Sprintf("Optional('%s')", spec.Name))
+		} else {
+			names = append(names, fmt.Sprintf("'%s'", spec.Name))
+		}
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+// Dispatch : validate and authorize an invocation, and call the handler of the function.
+func (r *Registry) Dispatch(APIstub shim.ChaincodeStubInterface) sc.Response {
+	name, args := APIstub.GetFunctionAndParameters()
+	logger.Infof("invoke %s, args=%s\n", name, args)
+
+	function, ok := r.Lookup(name)
+	if !ok {
+		msg := fmt.Sprintf("No such function. function = %s, args = %s", name, args)
+		logger.Error(msg)
+		return shim.Error(msg)
+	}
+
+	config, err := utils.GetConfig(APIstub)
+	if err != nil {
+		logger.Error(err.Error())
+		return shim.Error(err.Error())
+	}
+	if !config.Enabled(function.Name) {
+		msg := fmt.Sprintf("function is disabled by the configuration, function = %s", function.Name)
+		warning := &utils.WarningResult{StatusCode: 403, Message: msg}
+		logger.Warning(warning.Error())
+		return shim.Success(warning.JSONBytes())
+	}
+
+	args, err = params.Parse(function.Name, function.Params, args)
+	if err != nil {
+		warning := &utils.WarningResult{StatusCode: 400, Message: err.Error()}
+		logger.Warning(warning.Error())
+		return shim.Success(warning.JSONBytes())
+	}
+
+	if len(args) < params.Required(function.Params) || len(args) > len(function.Params) {
+		errMsg := fmt.Sprintf("Incorrect number of arguments. Expecting = %s, Actual = %s\n", expecting(function.Params), args)
+		logger.Error(errMsg)
+		return shim.Error(errMsg)
+	}
+
+	args, err = validate(APIstub, function.Params, args, config)
+	if err != nil {
+		switch e := err.(type) {
+		case *utils.WarningResult:
+			logger.Warning(err.Error())
+			return shim.Success(e.JSONBytes())
+		default:
+			logger.Error(err.Error())
+			return shim.Error(err.Error())
+		}
+	}
+
+	if len(function.Roles) > 0 {
+		if err := utils.CheckRole(APIstub, function.Roles...); err != nil {
+			switch e := err.(type) {
+			case *utils.WarningResult:
+				logger.Warning(err.Error())
+				return shim.Success(e.JSONBytes())
+			default:
+				logger.Error(err.Error())
+				return shim.Error(err.Error())
+			}
+		}
+	}
+
+	if function.ReadOnly {
+		return function.Handler(&readOnlyStub{APIstub}, args)
+	}
+	return function.Handler(APIstub, args)
+}
@@ -0,0 +1,67 @@
+/*
+ Package registry provides the function registry and the dispatcher of this chaincode.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package registry
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/repositories"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+// validate : validate the arguments by the formats of their specs, and return them normalized.
+func validate(APIstub shim.ChaincodeStubInterface, specs []params.Param, args []string, config *models.Config) ([]string, error) {
+	validated := make([]string, len(args))
+	for i, arg := range args {
+		var err error
+		validated[i] = arg
+		switch specs[i].Format {
+		case params.AccountNoFormat:
+			err = utils.CheckAccountNo(arg)
+		case params.CheckedAccountNoFormat:
+			err = checkLegacyAccountNo(APIstub, arg, config, utils.CheckAccountNoCheckDigit(arg))
+		case params.EventNoFormat:
+			err = utils.CheckEventNo(arg)
+		case params.NameFormat:
+			validated[i], err = utils.GetName(arg)
+		case params.MemoFormat:
+			validated[i], err = utils.GetMemo(arg)
+		case params.ReferenceFormat:
+			err = utils.CheckReference(arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return validated, nil
+}
+
+// checkLegacyAccountNo : accept an account no whose check digit is invalid only when its account is marked as legacy_no.
+//    the state is read only when the check digit fails, and checkErr is returned unless the account is legacy.
+func checkLegacyAccountNo(APIstub shim.ChaincodeStubInterface, no string, config *models.Config, checkErr error) error {
+	if checkErr == nil || utils.CheckAccountNo(no) != nil {
+		return checkErr
+	}
+	repos, err := repositories.ForStorageMode(APIstub, config.StorageMode)
+	if err != nil {
+		return err
+	}
+	account, err := repos.Accounts.Get(no)
+	if err != nil {
+		if _, ok := err.(*utils.WarningResult); ok {
+			return checkErr
+		}
+		return err
+	}
+	if !account.LegacyNo {
+		return checkErr
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+/*
+ Package repositories provides the storages of accounts and events.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package repositories
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+// compositeKeyAccountRepository : stores accounts under the composite keys ("account", no)
+//    and evaluates queries in memory over a range scan.
+type compositeKeyAccountRepository struct {
+	APIstub shim.ChaincodeStubInterface
+}
+
+// compositeKeyEventRepository : stores events under the composite keys ("event", no)
+//    and evaluates queries in memory over a range scan.
+type compositeKeyEventRepository struct {
+	APIstub shim.ChaincodeStubInterface
+}
+
+// compositeKeyJournalRepository : stores journal entries under the composite keys ("journal", no)
+//    and evaluates queries in memory over a range scan.
+type compositeKeyJournalRepository struct {
+	APIstub shim.ChaincodeStubInterface
+}
+
+// compositeKeyExists : return a StateExists which checks the no under the composite key of the model type.
+func compositeKeyExists(APIstub shim.ChaincodeStubInterface, modelType types.ModelType) utils.StateExists {
+	return func(no string) (bool, error) {
+		key, err := APIstub.CreateCompositeKey(modelType.String(), []string{no})
+		if err != nil {
+			return false, err
+		}
+		existing, err := APIstub.GetState(key)
+		if err != nil {
+			return false, err
+		}
+		return existing != nil, nil
+	}
+}
+
+// scan : return all raw values of a model type in the order of their nos.
+func scan(APIstub shim.ChaincodeStubInterface, modelType types.ModelType) ([][]byte, error) {
+	resultsIterator, err := APIstub.GetStateByPartialCompositeKey(modelType.String(), []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	values := make([][]byte, 0)
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, response.Value)
+	}
+	return values, nil
+}
+
+// isOutdated : return whether a raw document is older than the schema version, before upgrading it in memory.
+func isOutdated(value []byte, schemaVersion int) (bool, error) {
+	var version struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(value, &version); err != nil {
+		return false, err
+	}
+	return version.SchemaVersion < schemaVersion, nil
+}
+
+func (r *compositeKeyAccountRepository) NewNo() (string, error) {
+	return utils.GetAccountNo(compositeKeyExists(r.APIstub, types.AccountModel))
+}
+
+func (r *compositeKeyAccountRepository) Key(no string) (string, error) {
+	return r.APIstub.CreateCompositeKey(types.AccountModel.String(), []string{no})
+}
+
+func (r *compositeKeyAccountRepository) Get(no string) (*models.Account, error) {
+	key, err := r.Key(no)
+	if err != nil {
+		return nil, err
+	}
+	accountBytes, err := r.APIstub.GetState(key)
+	if err != nil {
+		return nil, err
+	} else if accountBytes == nil {
+		return nil, accountNotFound(no)
+	}
+	return utils.UnmarshalAccount(accountBytes)
+}
+
+func (r *compositeKeyAccountRepository) Put(account *models.Account) error {
+	key, err := r.Key(account.No)
+	if err != nil {
+		return err
+	}
+	accountBytes, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+	return r.APIstub.PutState(key, accountBytes)
+}
+
+func (r *compositeKeyAccountRepository) Delete(no string) error {
+	key, err := r.Key(no)
+	if err != nil {
+		return err
+	}
+	return r.APIstub.DelState(key)
+}
+
+func (r *compositeKeyAccountRepository) Query(q *AccountQuery) ([]*models.Account, error) {
+	match, err := accountMatcher(q)
+	if err != nil {
+		return nil, err
+	}
+	values, err := scan(r.APIstub, types.AccountModel)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]*models.Account, 0)
+	for _, value := range values {
+		account, err := utils.UnmarshalAccount(value)
+		if err != nil {
+			return nil, err
+		}
+		if match(account) {
+			accounts = append(accounts, account)
+		}
+	}
+	sortAccounts(accounts, q.Sort)
+	start, end := page(len(accounts), q.Limit, q.Skip)
+	return accounts[start:end], nil
+}
+
+func (r *compositeKeyAccountRepository) Migrate(limit int) ([]string, error) {
+	values, err := scan(r.APIstub, types.AccountModel)
+	if err != nil {
+		return nil, err
+	}
+	nos := make([]string, 0)
+	for _, value := range values {
+		if len(nos) == limit {
+			break
+		}
+		outdated, err := isOutdated(value, models.AccountSchemaVersion)
+		if err != nil {
+			return nil, err
+		}
+		if !outdated {
+			continue
+		}
+		account, err := utils.UnmarshalAccount(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.Put(account); err != nil {
+			return nil, err
+		}
+		nos = append(nos, account.No)
+	}
+	return nos, nil
+}
+
+func (r *compositeKeyEventRepository) key(no string) (string, error) {
+	return r.APIstub.CreateCompositeKey(types.EventModel.String(), []string{no})
+}
+
+func (r *compositeKeyEventRepository) NewNo() (string, error) {
+	return utils.GetEventNo(compositeKeyExists(r.APIstub, types.EventModel))
+}
+
+func (r *compositeKeyEventRepository) Get(no string) (*models.Event, error) {
+	key, err := r.key(no)
+	if err != nil {
+		return nil, err
+	}
+	eventBytes, err := r.APIstub.GetState(key)
+	if err != nil {
+		return nil, err
+	} else if eventBytes == nil {
+		return nil, eventNotFound(no)
+	}
+	return utils.UnmarshalEvent(eventBytes)
+}
+
+func (r *compositeKeyEventRepository) Put(event *models.Event) error {
+	key, err := r.key(event.No)
+	if err != nil {
+		return err
+	}
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return r.APIstub.PutState(key, eventBytes)
+}
+
+func (r *compositeKeyEventRepository) Query(q *EventQuery) ([]*models.Event, error) {
+	values, err := scan(r.APIstub, types.EventModel)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*models.Event, 0)
+	for _, value := range values {
+		event, err := utils.UnmarshalEvent(value)
+		if err != nil {
+			return nil, err
+		}
+		if matchEvent(q.Filter, event) {
+			events = append(events, event)
+		}
+	}
+	events = sortEvents(events, q.Sort)
+	start, end := page(len(events), q.Limit, q.Skip)
+	return events[start:end], nil
+}
+
+func (r *compositeKeyEventRepository) Migrate(limit int) ([]string, error) {
+	values, err := scan(r.APIstub, types.EventModel)
+	if err != nil {
+		return nil, err
+	}
+	nos := make([]string, 0)
+	for _, value := range values {
+		if len(nos) == limit {
+			break
+		}
+		outdated, err := isOutdated(value, models.EventSchemaVersion)
+		if err != nil {
+			return nil, err
+		}
+		if !outdated {
+			continue
+		}
+		event, err := utils.UnmarshalEvent(value)
+		if err != nil {
+			return nil, err
+		}
+		key, err := r.key(event.No)
+		if err != nil {
+			return nil, err
+		}
+		if err := backfillTimestamp(r.APIstub, key, event); err != nil {
+			return nil, err
+		}
+		if err := r.Put(event); err != nil {
+			return nil, err
+		}
+		nos = append(nos, event.No)
+	}
+	return nos, nil
+}
+
+func (r *compositeKeyJournalRepository) key(no string) (string, error) {
+	return r.APIstub.CreateCompositeKey(types.JournalModel.String(), []string{no})
+}
+
+func (r *compositeKeyJournalRepository) NewNo() (string, error) {
+	return utils.GetJournalNo(compositeKeyExists(r.APIstub, types.JournalModel))
+}
+
+func (r *compositeKeyJournalRepository) Get(no string) (*models.JournalEntry, error) {
+	key, err := r.key(no)
+	if err != nil {
+		return nil, err
+	}
+	journalBytes, err := r.APIstub.GetState(key)
+	if err != nil {
+		return nil, err
+	} else if journalBytes == nil {
+		return nil, journalNotFound(no)
+	}
+	journal := new(models.JournalEntry)
+	if err := json.Unmarshal(journalBytes, journal); err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+func (r *compositeKeyJournalRepository) Put(journal *models.JournalEntry) error {
+	key, err := r.key(journal.No)
+	if err != nil {
+		return err
+	}
+	journalBytes, err := json.Marshal(journal)
+	if err != nil {
+		return err
+	}
+	return r.APIstub.PutState(key, journalBytes)
+}
+
+func (r *compositeKeyJournalRepository) Query(q *JournalQuery) ([]*models.JournalEntry, error) {
+	values, err := scan(r.APIstub, types.JournalModel)
+	if err != nil {
+		return nil, err
+	}
+	journals := make([]*models.JournalEntry, 0, len(values))
+	for _, value := range values {
+		journal := new(models.JournalEntry)
+		if err := json.Unmarshal(value, journal); err != nil {
+			return nil, err
+		}
+		if q.AccountNo == "" || hasAccountNo(journal, q.AccountNo) {
+			journals = append(journals, journal)
+		}
+	}
+	sortJournals(journals)
+	start, end := page(len(journals), q.Limit, q.Skip)
+	return journals[start:end], nil
+}
@@ -0,0 +1,383 @@
+/*
+ Package repositories provides the storages of accounts and events.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package repositories
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+// couchDBAccountRepository : stores accounts under their nos and queries them by CouchDB rich queries.
+type couchDBAccountRepository struct {
+	APIstub shim.ChaincodeStubInterface
+}
+
+// couchDBEventRepository : stores events under their nos and queries them by CouchDB rich queries.
+type couchDBEventRepository struct {
+	APIstub shim.ChaincodeStubInterface
+}
+
+// couchDBJournalRepository : stores journal entries under their nos and queries them by CouchDB rich queries.
+type couchDBJournalRepository struct {
+	APIstub shim.ChaincodeStubInterface
+}
+
+// getQueryResult : run a CouchDB query and return the raw values.
+func getQueryResult(APIstub shim.ChaincodeStubInterface, query map[string]interface{}) ([][]byte, error) {
+	queryBytes, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	repositoryLogger.Infof("Query string = '%s'", string(queryBytes))
+	resultsIterator, err := APIstub.GetQueryResult(string(queryBytes))
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	values := make([][]byte, 0)
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, queryResponse.Value)
+	}
+	return values, nil
+}
+
+// outdatedQuery : return the query of the documents older than the latest schema version.
+func outdatedQuery(modelType types.ModelType, schemaVersion int, limit int) map[string]interface{} {
+	return map[string]interface{}{
+		"selector": map[string]interface{}{
+			"model_type": modelType,
+			"$or": []interface{}{
+				map[string]interface{}{
+					"schema_version": map[string]interface{}{"$exists": false},
+				},
+				map[string]interface{}{
+					"schema_version": map[string]interface{}{"$lt": schemaVersion},
+				},
+			},
+		},
+		"limit": limit,
+	}
+}
+
+// applyPage : add the sort and the page to a CouchDB query, and return whether CouchDB can sort it.
+//    when it cannot, neither the sort nor the page is added, and both have to be applied in memory.
+func applyPage(query map[string]interface{}, sort *utils.Sort, limit int, skip int) bool {
+	if sort != nil && !sort.Apply(query) {
+		return false
+	}
+	if limit > 0 {
+		query["limit"] = limit
+	}
+	if skip > 0 {
+		query["skip"] = skip
+	}
+	return true
+}
+
+func (r *couchDBAccountRepository) NewNo() (string, error) {
+	return utils.GetAccountNo(utils.KeyExists(r.APIstub))
+}
+
+func (r *couchDBAccountRepository) Key(no string) (string, error) {
+	return no, nil
+}
+
+func (r *couchDBAccountRepository) Get(no string) (*models.Account, error) {
+	accountBytes, err := r.APIstub.GetState(no)
+	if err != nil {
+		return nil, err
+	} else if accountBytes == nil {
+		return nil, accountNotFound(no)
+	}
+	account, err := utils.UnmarshalAccount(accountBytes)
+	if err != nil {
+		return nil, err
+	}
+	if account.ModelType != types.AccountModel {
+		return nil, accountNotFound(no)
+	}
+	return account, nil
+}
+
+func (r *couchDBAccountRepository) Put(account *models.Account) error {
+	accountBytes, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+	return r.APIstub.PutState(account.No, accountBytes)
+}
+
+func (r *couchDBAccountRepository) Delete(no string) error {
+	return r.APIstub.DelState(no)
+}
+
+func (r *couchDBAccountRepository) Query(q *AccountQuery) ([]*models.Account, error) {
+	selector := map[string]interface{}{
+		"model_type": types.AccountModel,
+	}
+	if q.Overdrawn {
+		selector["balance"] = map[string]interface{}{
+			"$lt": 0,
+		}
+	}
+	if q.InCredit {
+		selector["balance"] = map[string]interface{}{
+			"$gt": 0,
+		}
+	}
+	if q.NameTerm != "" {
+		selector["name"] = map[string]interface{}{
+			"$regex": namePattern(q),
+		}
+	}
+	query := map[string]interface{}{
+		"selector": selector,
+	}
+	sorted := applyPage(query, q.Sort, q.Limit, q.Skip)
+
+	values, err := getQueryResult(r.APIstub, query)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]*models.Account, 0, len(values))
+	for _, value := range values {
+		account, err := utils.UnmarshalAccount(value)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	if !sorted {
+		sortAccounts(accounts, q.Sort)
+		start, end := page(len(accounts), q.Limit, q.Skip)
+		accounts = accounts[start:end]
+	}
+	return accounts, nil
+}
+
+func (r *couchDBAccountRepository) Migrate(limit int) ([]string, error) {
+	values, err := getQueryResult(r.APIstub, outdatedQuery(types.AccountModel, models.AccountSchemaVersion, limit))
+	if err != nil {
+		return nil, err
+	}
+	nos := make([]string, 0, len(values))
+	for _, value := range values {
+		account, err := utils.UnmarshalAccount(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.Put(account); err != nil {
+			return nil, err
+		}
+		nos = append(nos, account.No)
+	}
+	return nos, nil
+}
+
+// eventFilterQuery : build a CouchDB query from an event filter.
+//    use_index picks the most selective index among the fields in the filter, if any.
+func eventFilterQuery(filter *models.EventFilter) map[string]interface{} {
+	selector := map[string]interface{}{
+		"model_type": types.EventModel,
+	}
+	var index []string
+
+	if len(filter.EventTypes) > 0 {
+		selector["event_type"] = map[string]interface{}{
+			"$in": filter.EventTypes,
+		}
+		index = []string{"_design/modelEventIndexDoc", "modelEventIndex"}
+	}
+	if len(filter.AccountNos) > 0 {
+		selector["$or"] = []interface{}{
+			map[string]interface{}{"from_account.no": map[string]interface{}{"$in": filter.AccountNos}},
+			map[string]interface{}{"to_account.no": map[string]interface{}{"$in": filter.AccountNos}},
+		}
+	}
+	if filter.AmountMin != nil || filter.AmountMax != nil {
+		amount := map[string]interface{}{}
+		if filter.AmountMin != nil {
+			amount["$gte"] = *filter.AmountMin
+		}
+		if filter.AmountMax != nil {
+			amount["$lte"] = *filter.AmountMax
+		}
+		selector["amount"] = amount
+	}
+	if filter.From != "" || filter.To != "" {
+		timestamp := map[string]interface{}{}
+		if filter.From != "" {
+			timestamp["$gte"] = filter.From
+		}
+		if filter.To != "" {
+			timestamp["$lt"] = filter.To
+		}
+		selector["timestamp"] = timestamp
+		index = []string{"_design/modelTimestampIndexDoc", "modelTimestampIndex"}
+	}
+	if filter.Reference != "" {
+		selector["reference"] = filter.Reference
+		index = []string{"_design/modelReferenceIndexDoc", "modelReferenceIndex"}
+	}
+
+	query := map[string]interface{}{
+		"selector": selector,
+	}
+	if index != nil {
+		query["use_index"] = index
+	}
+	return query
+}
+
+func (r *couchDBEventRepository) NewNo() (string, error) {
+	return utils.GetEventNo(utils.KeyExists(r.APIstub))
+}
+
+func (r *couchDBEventRepository) Get(no string) (*models.Event, error) {
+	eventBytes, err := r.APIstub.GetState(no)
+	if err != nil {
+		return nil, err
+	} else if eventBytes == nil {
+		return nil, eventNotFound(no)
+	}
+	event, err := utils.UnmarshalEvent(eventBytes)
+	if err != nil {
+		return nil, err
+	}
+	if event.ModelType != types.EventModel {
+		return nil, eventNotFound(no)
+	}
+	return event, nil
+}
+
+func (r *couchDBEventRepository) Put(event *models.Event) error {
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return r.APIstub.PutState(event.No, eventBytes)
+}
+
+func (r *couchDBEventRepository) Query(q *EventQuery) ([]*models.Event, error) {
+	filter := q.Filter
+	if filter == nil {
+		filter = new(models.EventFilter)
+	}
+	query := eventFilterQuery(filter)
+	sorted := applyPage(query, q.Sort, q.Limit, q.Skip)
+
+	values, err := getQueryResult(r.APIstub, query)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*models.Event, 0, len(values))
+	for _, value := range values {
+		event, err := utils.UnmarshalEvent(value)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if !sorted {
+		events = sortEvents(events, q.Sort)
+		start, end := page(len(events), q.Limit, q.Skip)
+		events = events[start:end]
+	}
+	return events, nil
+}
+
+func (r *couchDBEventRepository) Migrate(limit int) ([]string, error) {
+	values, err := getQueryResult(r.APIstub, outdatedQuery(types.EventModel, models.EventSchemaVersion, limit))
+	if err != nil {
+		return nil, err
+	}
+	nos := make([]string, 0, len(values))
+	for _, value := range values {
+		event, err := utils.UnmarshalEvent(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := backfillTimestamp(r.APIstub, event.No, event); err != nil {
+			return nil, err
+		}
+		if err := r.Put(event); err != nil {
+			return nil, err
+		}
+		nos = append(nos, event.No)
+	}
+	return nos, nil
+}
+
+func (r *couchDBJournalRepository) NewNo() (string, error) {
+	return utils.GetJournalNo(utils.KeyExists(r.APIstub))
+}
+
+func (r *couchDBJournalRepository) Get(no string) (*models.JournalEntry, error) {
+	journalBytes, err := r.APIstub.GetState(no)
+	if err != nil {
+		return nil, err
+	} else if journalBytes == nil {
+		return nil, journalNotFound(no)
+	}
+	journal := new(models.JournalEntry)
+	if err := json.Unmarshal(journalBytes, journal); err != nil {
+		return nil, err
+	}
+	if journal.ModelType != types.JournalModel {
+		return nil, journalNotFound(no)
+	}
+	return journal, nil
+}
+
+func (r *couchDBJournalRepository) Put(journal *models.JournalEntry) error {
+	journalBytes, err := json.Marshal(journal)
+	if err != nil {
+		return err
+	}
+	return r.APIstub.PutState(journal.No, journalBytes)
+}
+
+func (r *couchDBJournalRepository) Query(q *JournalQuery) ([]*models.JournalEntry, error) {
+	selector := map[string]interface{}{
+		"model_type": types.JournalModel,
+	}
+	if q.AccountNo != "" {
+		selector["account_nos"] = map[string]interface{}{
+			"$elemMatch": map[string]interface{}{
+				"$eq": q.AccountNo,
+			},
+		}
+	}
+
+	values, err := getQueryResult(r.APIstub, map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, err
+	}
+	journals := make([]*models.JournalEntry, 0, len(values))
+	for _, value := range values {
+		journal := new(models.JournalEntry)
+		if err := json.Unmarshal(value, journal); err != nil {
+			return nil, err
+		}
+		journals = append(journals, journal)
+	}
+	// no index supports sorting journals, so they are sorted and paged in memory
+	sortJournals(journals)
+	start, end := page(len(journals), q.Limit, q.Skip)
+	return journals[start:end], nil
+}
@@ -0,0 +1,168 @@
+/*
+ Package repositories provides the storages of accounts and events.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package repositories
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+// namePattern : return the regular expression of the name condition, which both CouchDB and Go understand.
+func namePattern(q *AccountQuery) string {
+	if q.NamePrefix {
+		return "(?i)^" + regexp.QuoteMeta(q.NameTerm)
+	}
+	return "(?i)" + regexp.QuoteMeta(q.NameTerm)
+}
+
+// accountMatcher : return a function to evaluate an account query in memory.
+func accountMatcher(q *AccountQuery) (func(*models.Account) bool, error) {
+	var name *regexp.Regexp
+	if q.NameTerm != "" {
+		var err error
+		name, err = regexp.Compile(namePattern(q))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return func(account *models.Account) bool {
+		if q.Overdrawn && account.Balance >= 0 {
+			return false
+		}
+		if q.InCredit && account.Balance <= 0 {
+			return false
+		}
+		if name != nil && !name.MatchString(account.Name) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// matchEvent : evaluate an event filter in memory.
+func matchEvent(filter *models.EventFilter, event *models.Event) bool {
+	if filter == nil {
+		return true
+	}
+	if len(filter.EventTypes) > 0 {
+		found := false
+		for _, eventType := range filter.EventTypes {
+			found = found || event.EventType == eventType
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(filter.AccountNos) > 0 {
+		found := false
+		for _, no := range filter.AccountNos {
+			found = found ||
+				(event.FromAccountState != nil && event.FromAccountState.No == no) ||
+				(event.ToAccountState != nil && event.ToAccountState.No == no)
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.AmountMin != nil && event.Amount < *filter.AmountMin {
+		return false
+	}
+	if filter.AmountMax != nil && event.Amount > *filter.AmountMax {
+		return false
+	}
+	if filter.From != "" && event.Timestamp < filter.From {
+		return false
+	}
+	if filter.To != "" && event.Timestamp >= filter.To {
+		return false
+	}
+	if filter.Reference != "" && event.Reference != filter.Reference {
+		return false
+	}
+	return true
+}
+
+// sortAccounts : sort accounts in memory.
+//    names are compared by their bytes, not by the ICU collation of CouchDB, so mixed-case names may be ordered differently.
+func sortAccounts(accounts []*models.Account, s *utils.Sort) {
+	if s == nil {
+		return
+	}
+	less := func(i, j int) bool {
+		switch s.Field {
+		case "balance":
+			return accounts[i].Balance < accounts[j].Balance
+		default:
+			return accounts[i].Name < accounts[j].Name
+		}
+	}
+	if s.Order == "desc" {
+		sort.SliceStable(accounts, func(i, j int) bool { return less(j, i) })
+	} else {
+		sort.SliceStable(accounts, less)
+	}
+}
+
+// sortEvents : sort events in memory, and return them.
+//    the events without timestamp are dropped, as CouchDB drops the documents which the sort index does not have.
+func sortEvents(events []*models.Event, s *utils.Sort) []*models.Event {
+	if s == nil {
+		return events
+	}
+	timestamped := make([]*models.Event, 0, len(events))
+	for _, event := range events {
+		if event.Timestamp != "" {
+			timestamped = append(timestamped, event)
+		}
+	}
+	events = timestamped
+	less := func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	}
+	if s.Order == "desc" {
+		sort.SliceStable(events, func(i, j int) bool { return less(j, i) })
+	} else {
+		sort.SliceStable(events, less)
+	}
+	return events
+}
+
+// hasAccountNo : return whether a journal entry has a line of an account.
+func hasAccountNo(journal *models.JournalEntry, accountNo string) bool {
+	for _, no := range journal.AccountNos {
+		if no == accountNo {
+			return true
+		}
+	}
+	return false
+}
+
+// sortJournals : sort journal entries by timestamp and no, so that both storage modes list them in the same order.
+func sortJournals(journals []*models.JournalEntry) {
+	sort.SliceStable(journals, func(i, j int) bool {
+		if journals[i].Timestamp != journals[j].Timestamp {
+			return journals[i].Timestamp < journals[j].Timestamp
+		}
+		return journals[i].No < journals[j].No
+	})
+}
+
+// page : return the range [skip, skip+limit) of n items. zero limit means no limit.
+func page(n int, limit int, skip int) (int, int) {
+	if skip > n {
+		skip = n
+	}
+	end := n
+	if limit > 0 && skip+limit < n {
+		end = skip + limit
+	}
+	return skip, end
+}
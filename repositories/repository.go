@@ -0,0 +1,164 @@
+/*
+ Package repositories provides the storages of accounts and events.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/utils"
+)
+
+var repositoryLogger = shim.NewLogger("repositories")
+
+// AccountSortIndexes : the indexes which support sorting accounts.
+var AccountSortIndexes = []utils.SortIndex{
+	{Field: "name", DesignDoc: "modelNameIndexDoc", Name: "modelNameIndex"},
+	{Field: "balance", DesignDoc: "modelBalanceIndexDoc", Name: "modelBalanceIndex"},
+}
+
+// EventSortIndexes : the indexes which support sorting events.
+var EventSortIndexes = []utils.SortIndex{
+	{Field: "timestamp", DesignDoc: "modelTimestampIndexDoc", Name: "modelTimestampIndex"},
+}
+
+// AccountQuery : the conditions to query accounts. the zero value of each field means no condition.
+//    NameTerm matches a name case-insensitively, as a prefix when NamePrefix is true or as a substring otherwise.
+type AccountQuery struct {
+	Overdrawn  bool
+	InCredit   bool
+	NameTerm   string
+	NamePrefix bool
+	Sort       *utils.Sort
+	Limit      int
+	Skip       int
+}
+
+// EventQuery : the conditions to query events. the zero value of each field means no condition.
+type EventQuery struct {
+	Filter *models.EventFilter
+	Sort   *utils.Sort
+	Limit  int
+	Skip   int
+}
+
+// JournalQuery : the conditions to query journal entries. the zero value of each field means no condition.
+type JournalQuery struct {
+	AccountNo string
+	Limit     int
+	Skip      int
+}
+
+// AccountRepository : the storage of accounts.
+type AccountRepository interface {
+	// NewNo : return an account no which is not used yet.
+	NewNo() (string, error)
+	// Key : return the state key of an account, to read the history of it.
+	Key(no string) (string, error)
+	// Get : return an account, or a 404 WarningResult when it does not exist.
+	Get(no string) (*models.Account, error)
+	Put(account *models.Account) error
+	Delete(no string) error
+	Query(query *AccountQuery) ([]*models.Account, error)
+	// Migrate : rewrite at most limit accounts older than the latest schema version, and return their nos.
+	Migrate(limit int) ([]string, error)
+}
+
+// EventRepository : the storage of events.
+type EventRepository interface {
+	// NewNo : return an event no which is not used yet.
+	NewNo() (string, error)
+	// Get : return an event, or a 404 WarningResult when it does not exist.
+	Get(no string) (*models.Event, error)
+	Put(event *models.Event) error
+	Query(query *EventQuery) ([]*models.Event, error)
+	// Migrate : rewrite at most limit events older than the latest schema version, and return their nos.
+	Migrate(limit int) ([]string, error)
+}
+
+// JournalRepository : the storage of journal entries.
+type JournalRepository interface {
+	// NewNo : return a journal no which is not used yet.
+	NewNo() (string, error)
+	// Get : return a journal entry, or a 404 WarningResult when it does not exist.
+	Get(no string) (*models.JournalEntry, error)
+	Put(journal *models.JournalEntry) error
+	// Query : return the journal entries sorted by timestamp and no.
+	Query(query *JournalQuery) ([]*models.JournalEntry, error)
+}
+
+// Repositories : a holder of the repositories of the configured storage mode.
+type Repositories struct {
+	Accounts AccountRepository
+	Events   EventRepository
+	Journals JournalRepository
+}
+
+// New : return the repositories of the storage mode in the configuration.
+func New(APIstub shim.ChaincodeStubInterface) (*Repositories, error) {
+	config, err := utils.GetConfig(APIstub)
+	if err != nil {
+		return nil, err
+	}
+	return ForStorageMode(APIstub, config.StorageMode)
+}
+
+// ForStorageMode : return the repositories of a storage mode.
+func ForStorageMode(APIstub shim.ChaincodeStubInterface, storageMode string) (*Repositories, error) {
+	switch storageMode {
+	case models.CouchDBStorageMode:
+		return &Repositories{
+			Accounts: &couchDBAccountRepository{APIstub},
+			Events:   &couchDBEventRepository{APIstub},
+			Journals: &couchDBJournalRepository{APIstub},
+		}, nil
+	case models.CompositeKeyStorageMode:
+		return &Repositories{
+			Accounts: &compositeKeyAccountRepository{APIstub},
+			Events:   &compositeKeyEventRepository{APIstub},
+			Journals: &compositeKeyJournalRepository{APIstub},
+		}, nil
+	default:
+		return nil, fmt.Errorf("storage_mode is not supported, storage_mode = %s", storageMode)
+	}
+}
+
+// backfillTimestamp : set the timestamp of an event stored before the timestamp was recorded
+//    to the timestamp of the transaction which created its key, and upgrade it to the latest schema version.
+func backfillTimestamp(APIstub shim.ChaincodeStubInterface, key string, event *models.Event) error {
+	if event.Timestamp != "" {
+		return nil
+	}
+	timestamp, err := utils.GetCreatedTimestamp(APIstub, key)
+	if err != nil {
+		return err
+	}
+	event.Timestamp = timestamp
+	event.Upgrade()
+	return nil
+}
+
+func accountNotFound(no string) error {
+	msg := fmt.Sprintf("Account does not exist, no = %s", no)
+	warning := &utils.WarningResult{StatusCode: 404, Message: msg}
+	return warning
+}
+
+func journalNotFound(no string) error {
+	msg := fmt.Sprintf("Journal does not exist, no = %s", no)
+	warning := &utils.WarningResult{StatusCode: 404, Message: msg}
+	return warning
+}
+
+func eventNotFound(no string) error {
+	msg := fmt.Sprintf("Event does not exist, no = %s", no)
+	warning := &utils.WarningResult{StatusCode: 404, Message: msg}
+	return warning
+}
@@ -0,0 +1,157 @@
+/*
+ Package schema provides the JSON Schemas of the arguments and the results of chaincode functions.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
+)
+
+// Draft : the JSON Schema draft which the schemas conform to.
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// Schema : a subset of JSON Schema draft-07.
+//    Type is a string, or a list of strings when the value is nullable.
+type Schema struct {
+	Type                 interface{}        `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	ContentMediaType     string             `json:"contentMediaType,omitempty"`
+	Format               string             `json:"format,omitempty"`
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Of : return the schema of the JSON representation of a value.
+func Of(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{Type: "null"}
+	}
+	return of(reflect.TypeOf(v))
+}
+
+// Text : return the schema of a non-JSON result such as XML or CSV.
+func Text(mediaType string) *Schema {
+	return &Schema{Type: "string", ContentMediaType: mediaType}
+}
+
+// Arguments : return the schema of the named arguments of a function.
+func Arguments(specs []params.Param) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		Required:             make([]string, 0),
+		AdditionalProperties: false,
+	}
+	for _, spec := range specs {
+		property := &Schema{Type: string(spec.Kind), Format: string(spec.Format)}
+		if spec.Kind == params.ObjectKind {
+			property.Type = []string{string(params.ObjectKind), string(params.StringKind)}
+		}
+		if spec.Default != "" {
+			property.Default = spec.Default
+			if spec.Kind == params.IntegerKind {
+				property.Default = json.Number(spec.Default)
+			}
+		}
+		s.Properties[spec.Name] = property
+		if !spec.Optional {
+			s.Required = append(s.Required, spec.Name)
+		}
+	}
+	return s
+}
+
+func of(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		return of(t.Elem())
+	}
+	// the types which marshal themselves, like types.EventType, are rendered as strings
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: of(t.Elem())}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return &Schema{Type: "object", AdditionalProperties: true}
+		}
+		return &Schema{Type: "object", AdditionalProperties: of(t.Elem())}
+	case reflect.Struct:
+		return ofStruct(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func ofStruct(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{},
+		Required:   make([]string, 0),
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if index := strings.Index(tag, ","); index >= 0 {
+			name, options = tag[:index], tag[index:]
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := of(field.Type)
+		omitempty := strings.Contains(options, ",omitempty")
+		// a nil pointer, map, slice or interface without omitempty is marshaled as null
+		if !omitempty && isNullable(field.Type) && property.Type != nil {
+			property.Type = []interface{}{property.Type, "null"}
+		}
+		s.Properties[name] = property
+		if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+func isNullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return true
+	default:
+		return false
+	}
+}
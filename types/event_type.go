@@ -16,6 +16,8 @@ const (
 	depositEventStr  = "deposit"
 	remitEventStr    = "remit"
 	withdrawEventStr = "withdraw"
+	interestEventStr = "interest"
+	reversalEventStr = "reversal"
 )
 
 // EventType : event type
@@ -27,6 +29,8 @@ const (
 	DepositEvent
 	RemitEvent
 	WithdrawEvent
+	InterestEvent
+	ReversalEvent
 )
 
 // String : Striner interface
@@ -38,6 +42,10 @@ func (t EventType) String() string {
 		return remitEventStr
 	case WithdrawEvent:
 		return withdrawEventStr
+	case InterestEvent:
+		return interestEventStr
+	case ReversalEvent:
+		return reversalEventStr
 	default:
 		return unknownEventStr
 	}
@@ -62,6 +70,10 @@ func (t *EventType) UnmarshalJSON(data []byte) error {
 		*t = RemitEvent
 	case withdrawEventStr:
 		*t = WithdrawEvent
+	case interestEventStr:
+		*t = InterestEvent
+	case reversalEventStr:
+		*t = ReversalEvent
 	default:
 		*t = UnKnownEvent
 	}
@@ -12,9 +12,12 @@ import (
 )
 
 const (
-	unknownModelStr = "unknown"
-	accountModelStr = "account"
-	eventModelStr   = "event"
+	unknownModelStr        = "unknown"
+	accountModelStr        = "account"
+	eventModelStr          = "event"
+	interestConfigModelStr = "interest_config"
+	journalModelStr        = "journal"
+	configModelStr         = "config"
 )
 
 // ModelType : model type
@@ -25,6 +28,9 @@ const (
 	UnKnownModel ModelType = iota
 	AccountModel
 	EventModel
+	InterestConfigModel
+	JournalModel
+	ConfigModel
 )
 
 // String : Stringer interface
@@ -34,6 +40,12 @@ func (t ModelType) String() string {
 		return accountModelStr
 	case EventModel:
 		return eventModelStr
+	case InterestConfigModel:
+		return interestConfigModelStr
+	case JournalModel:
+		return journalModelStr
+	case ConfigModel:
+		return configModelStr
 	default:
 		return unknownModelStr
 	}
@@ -56,6 +68,12 @@ func (t *ModelType) UnmarshalJSON(data []byte) error {
 		*t = AccountModel
 	case eventModelStr:
 		*t = EventModel
+	case interestConfigModelStr:
+		*t = InterestConfigModel
+	case journalModelStr:
+		*t = JournalModel
+	case configModelStr:
+		*t = ConfigModel
 	default:
 		*t = UnKnownModel
 	}
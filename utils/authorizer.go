@@ -0,0 +1,77 @@
+/*
+ Package utils provides some utility functions.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package utils
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// RoleAttribute : the attribute name of the client certificate to hold the role.
+const RoleAttribute = "role"
+
+// concrete roles
+const (
+	AdminRole      = "admin"
+	AuditorRole    = "auditor"
+	ComplianceRole = "compliance"
+)
+
+// CheckRole : validate that the invoker has one of the roles.
+//    the invokers of the admin MSPs in the configuration have the admin role regardless of their attribute.
+func CheckRole(APIstub shim.ChaincodeStubInterface, roles ...string) error {
+	for _, role := range roles {
+		if role != AdminRole {
+			continue
+		}
+		isAdmin, err := isAdminMSP(APIstub)
+		if err != nil {
+			return err
+		}
+		if isAdmin {
+			return nil
+		}
+	}
+
+	value, found, err := cid.GetAttributeValue(APIstub, RoleAttribute)
+	if err != nil {
+		return err
+	}
+	if found {
+		for _, role := range roles {
+			if value == role {
+				return nil
+			}
+		}
+	}
+	msg := fmt.Sprintf("invoker does not have the role, roles = %s", roles)
+	warning := &WarningResult{StatusCode: 403, Message: msg}
+	return warning
+}
+
+func isAdminMSP(APIstub shim.ChaincodeStubInterface) (bool, error) {
+	config, err := GetConfig(APIstub)
+	if err != nil {
+		return false, err
+	}
+	if len(config.AdminMSPIDs) == 0 {
+		return false, nil
+	}
+	mspID, err := cid.GetMSPID(APIstub)
+	if err != nil {
+		return false, err
+	}
+	for _, adminMSPID := range config.AdminMSPIDs {
+		if mspID == adminMSPID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,179 @@
+/*
+ Package utils provides some utility functions.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+)
+
+// ConfigKey : the reserved key of the chaincode configuration.
+const ConfigKey = "CONFIG"
+
+// currencyPattern : ISO 4217 alphabetic code.
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// GetConfig : get the configuration from state db, or the default configuration when it is not stored yet.
+func GetConfig(APIstub shim.ChaincodeStubInterface) (*models.Config, error) {
+	config := models.NewConfig()
+	configBytes, err := APIstub.GetState(ConfigKey)
+	if err != nil {
+		return config, err
+	} else if configBytes == nil {
+		return config, nil
+	}
+	if err := json.Unmarshal(configBytes, config); err != nil {
+		return config, err
+	}
+	if config.Features == nil {
+		config.Features = map[string]bool{}
+	}
+	if config.AdminMSPIDs == nil {
+		config.AdminMSPIDs = make([]string, 0)
+	}
+	if config.KYCLimits == nil {
+		config.KYCLimits = map[int]models.KYCLimits{}
+	}
+	return config, nil
+}
+
+// MergeConfig : merge a JSON configuration object into config and validate it strictly.
+//    the fields in the object replace the current values, and the entries of features are merged one by one.
+func MergeConfig(config *models.Config, configStr string) error {
+	decoder := json.NewDecoder(bytes.NewBufferString(configStr))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		msg := fmt.Sprintf("config is invalid, error = %s, config = %s", err.Error(), configStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	if decoder.More() {
+		msg := fmt.Sprintf("config has trailing data, config = %s", configStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	config.ModelType = types.ConfigModel
+	if config.Features == nil {
+		config.Features = map[string]bool{}
+	}
+	if config.AdminMSPIDs == nil {
+		config.AdminMSPIDs = make([]string, 0)
+	}
+	if config.KYCLimits == nil {
+		config.KYCLimits = map[int]models.KYCLimits{}
+	}
+
+	if !currencyPattern.MatchString(config.Currency) {
+		msg := fmt.Sprintf("currency is not an ISO 4217 code, currency = %s", config.Currency)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	for _, mspID := range config.AdminMSPIDs {
+		if mspID == "" {
+			msg := fmt.Sprintf("admin_msp_ids has an empty MSP ID, config = %s", configStr)
+			warning := &WarningResult{StatusCode: 400, Message: msg}
+			return warning
+		}
+	}
+	if config.FeeCollectorAccountNo != "" {
+		if err := CheckAccountNo(config.FeeCollectorAccountNo); err != nil {
+			return err
+		}
+	}
+	if config.Limits.MaxAmount < 0 || config.Limits.MaxOverdraftLimit < 0 {
+		msg := fmt.Sprintf("max_amount or max_overdraft_limit is less than zero, config = %s", configStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	for level, limits := range config.KYCLimits {
+		if level < 0 || level > models.MaxKYCLevel {
+			msg := fmt.Sprintf("kyc_limits has a level not between 0 and %d, level = %d", models.MaxKYCLevel, level)
+			warning := &WarningResult{StatusCode: 400, Message: msg}
+			return warning
+		}
+		if limits.MaxBalance < 0 || limits.MaxWithdrawal < 0 {
+			msg := fmt.Sprintf("max_balance or max_withdrawal is less than zero, level = %d, config = %s", level, configStr)
+			warning := &WarningResult{StatusCode: 400, Message: msg}
+			return warning
+		}
+	}
+	switch config.StorageMode {
+	case models.CouchDBStorageMode, models.CompositeKeyStorageMode:
+	default:
+		msg := fmt.Sprintf("storage_mode is not supported, storage_mode = %s", config.StorageMode)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	return nil
+}
+
+// CheckMaxAmount : validate that an amount does not exceed the configured limit.
+func CheckMaxAmount(APIstub shim.ChaincodeStubInterface, amount int) error {
+	config, err := GetConfig(APIstub)
+	if err != nil {
+		return err
+	}
+	if config.Limits.MaxAmount > 0 && amount > config.Limits.MaxAmount {
+		msg := fmt.Sprintf("amount exceeds the limit, amount = %d, max_amount = %d", amount, config.Limits.MaxAmount)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	return nil
+}
+
+// CheckMaxOverdraftLimit : validate that an overdraft limit does not exceed the configured limit.
+func CheckMaxOverdraftLimit(APIstub shim.ChaincodeStubInterface, limit int) error {
+	config, err := GetConfig(APIstub)
+	if err != nil {
+		return err
+	}
+	if config.Limits.MaxOverdraftLimit > 0 && limit > config.Limits.MaxOverdraftLimit {
+		msg := fmt.Sprintf("overdraft limit exceeds the limit, overdraft_limit = %d, max_overdraft_limit = %d", limit, config.Limits.MaxOverdraftLimit)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	return nil
+}
+
+// CheckMaxBalance : validate that an account does not exceed the max balance of its KYC level by receiving the amount.
+//    every path which credits an account (deposit, remit, reversal and interest) checks it.
+func CheckMaxBalance(APIstub shim.ChaincodeStubInterface, account *models.Account, amount int) error {
+	config, err := GetConfig(APIstub)
+	if err != nil {
+		return err
+	}
+	limits := config.KYCLimitsFor(account)
+	if limits.MaxBalance > 0 && account.Balance+amount > limits.MaxBalance {
+		msg := fmt.Sprintf("balance exceeds the limit of the KYC level, amount = %d, balance = %d, kyc_level = %d, max_balance = %d, no = %s", amount, account.Balance, account.KYCLevel, limits.MaxBalance, account.No)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	return nil
+}
+
+// CheckMaxWithdrawal : validate that a withdrawal from an account does not exceed the limit of its KYC level.
+func CheckMaxWithdrawal(APIstub shim.ChaincodeStubInterface, account *models.Account, amount int) error {
+	config, err := GetConfig(APIstub)
+	if err != nil {
+		return err
+	}
+	limits := config.KYCLimitsFor(account)
+	if limits.MaxWithdrawal > 0 && amount > limits.MaxWithdrawal {
+		msg := fmt.Sprintf("amount exceeds the withdrawal limit of the KYC level, amount = %d, kyc_level = %d, max_withdrawal = %d, no = %s", amount, account.KYCLevel, limits.MaxWithdrawal, account.No)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+ Package utils provides some utility functions.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/types"
+)
+
+// GetEventFilter : convert a JSON filter object to EventFilter and validate it strictly
+func GetEventFilter(filterStr string) (*models.EventFilter, error) {
+	filter := new(models.EventFilter)
+	decoder := json.NewDecoder(bytes.NewBufferString(filterStr))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(filter); err != nil {
+		msg := fmt.Sprintf("filter is invalid, error = %s, filter = %s", err.Error(), filterStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return filter, warning
+	}
+	if decoder.More() {
+		msg := fmt.Sprintf("filter has trailing data, filter = %s", filterStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return filter, warning
+	}
+
+	for _, eventType := range filter.EventTypes {
+		if eventType == types.UnKnownEvent {
+			msg := fmt.Sprintf("event_types has an unknown event type, filter = %s", filterStr)
+			warning := &WarningResult{StatusCode: 400, Message: msg}
+			return filter, warning
+		}
+	}
+	for _, no := range filter.AccountNos {
+		if err := CheckAccountNo(no); err != nil {
+			return filter, err
+		}
+	}
+	if (filter.AmountMin != nil && *filter.AmountMin < 0) || (filter.AmountMax != nil && *filter.AmountMax < 0) {
+		msg := fmt.Sprintf("amount_min or amount_max is less than zero, filter = %s", filterStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return filter, warning
+	}
+	if filter.AmountMin != nil && filter.AmountMax != nil && *filter.AmountMin > *filter.AmountMax {
+		msg := fmt.Sprintf("amount_min is greater than amount_max, filter = %s", filterStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return filter, warning
+	}
+	if filter.From != "" {
+		from, err := GetTime(filter.From)
+		if err != nil {
+			return filter, err
+		}
+		filter.From = from
+	}
+	if filter.To != "" {
+		to, err := GetTime(filter.To)
+		if err != nil {
+			return filter, err
+		}
+		filter.To = to
+	}
+	if filter.From != "" && filter.To != "" && filter.From >= filter.To {
+		msg := fmt.Sprintf("from is not before to, filter = %s", filterStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return filter, warning
+	}
+	return filter, nil
+}
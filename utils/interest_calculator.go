@@ -0,0 +1,30 @@
+/*
+ Package utils provides some utility functions.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package utils
+
+const (
+	daysPerYear      = 365
+	basisPointsUnit  = 10000
+	interestDivision = daysPerYear * basisPointsUnit
+)
+
+// CalcInterest : calculate the simple interest of the end-of-day balances of a period.
+//    day count convention is Actual/365 Fixed, annualRate is expressed in basis points,
+//    a day whose balance is not positive earns nothing, and the result is rounded half up to an integer amount.
+func CalcInterest(dailyBalances []int, annualRate int) int {
+	if annualRate <= 0 {
+		return 0
+	}
+	balanceDays := 0
+	for _, balance := range dailyBalances {
+		if balance > 0 {
+			balanceDays += balance
+		}
+	}
+	return (balanceDays*annualRate + interestDivision/2) / interestDivision
+}
@@ -0,0 +1,84 @@
+/*
+ Package utils provides some utility functions.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+)
+
+// MaxAttributes : the maximum number of the attributes of an account.
+const MaxAttributes = 32
+
+var (
+	customerIDPattern   = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+	accountTypePattern  = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+	attributeKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,64}$`)
+)
+
+// GetAccountMetadata : convert a JSON metadata object to AccountMetadata and validate it strictly
+func GetAccountMetadata(metadataStr string) (*models.AccountMetadata, error) {
+	metadata := new(models.AccountMetadata)
+	decoder := json.NewDecoder(bytes.NewBufferString(metadataStr))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(metadata); err != nil {
+		msg := fmt.Sprintf("metadata is invalid, error = %s, metadata = %s", err.Error(), metadataStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return metadata, warning
+	}
+	if decoder.More() {
+		msg := fmt.Sprintf("metadata has trailing data, metadata = %s", metadataStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return metadata, warning
+	}
+
+	if metadata.CustomerID != nil && *metadata.CustomerID != "" && !customerIDPattern.MatchString(*metadata.CustomerID) {
+		msg := fmt.Sprintf("customer_id is not up to 64 alphanumeric characters, '_' or '-', customer_id = %q", *metadata.CustomerID)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return metadata, warning
+	}
+	if metadata.KYCLevel != nil && (*metadata.KYCLevel < 0 || *metadata.KYCLevel > models.MaxKYCLevel) {
+		msg := fmt.Sprintf("kyc_level is not between 0 and %d, kyc_level = %d", models.MaxKYCLevel, *metadata.KYCLevel)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return metadata, warning
+	}
+	if metadata.AccountType != nil && *metadata.AccountType != "" && !accountTypePattern.MatchString(*metadata.AccountType) {
+		msg := fmt.Sprintf("account_type is not up to 32 lowercase alphanumeric characters or '_', account_type = %q", *metadata.AccountType)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return metadata, warning
+	}
+	for key, value := range metadata.Attributes {
+		if !attributeKeyPattern.MatchString(key) {
+			msg := fmt.Sprintf("attributes has an invalid key, key = %q", key)
+			warning := &WarningResult{StatusCode: 400, Message: msg}
+			return metadata, warning
+		}
+		if value != nil {
+			memo, err := GetMemo(*value)
+			if err != nil {
+				return metadata, err
+			}
+			*value = memo
+		}
+	}
+	return metadata, nil
+}
+
+// CheckAttributes : validate the number of the attributes of an account
+func CheckAttributes(account *models.Account) error {
+	if len(account.Attributes) > MaxAttributes {
+		msg := fmt.Sprintf("attributes has more than %d keys, no = %s", MaxAttributes, account.No)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+/*
+ Package utils provides some utility functions.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package utils
+
+import (
+	"encoding/json"
+
+	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+)
+
+// UnmarshalAccount : unmarshal an account and upgrade it to the latest schema version in memory.
+func UnmarshalAccount(accountBytes []byte) (*models.Account, error) {
+	account := new(models.Account)
+	if err := json.Unmarshal(accountBytes, account); err != nil {
+		return account, err
+	}
+	account.Upgrade()
+	return account, nil
+}
+
+// UnmarshalEvent : unmarshal an event and upgrade it to the latest schema version in memory.
+func UnmarshalEvent(eventBytes []byte) (*models.Event, error) {
+	event := new(models.Event)
+	if err := json.Unmarshal(eventBytes, event); err != nil {
+		return event, err
+	}
+	event.Upgrade()
+	return event, nil
+}
@@ -43,34 +43,29 @@ func getRandomString(n int, letterBytes string) string {
 	return string(b)
 }
 
-// GetAccountNo : return a unique Account No.
-func GetAccountNo(APIstub shim.ChaincodeStubInterface) (string, error) {
-	var no string
-	for {
-		no = getRandomString(16, "0123456789")
+// StateExists : a function to check whether a state object of the no exists.
+type StateExists func(no string) (bool, error)
+
+// KeyExists : return a StateExists which checks the no as a plain state key.
+func KeyExists(APIstub shim.ChaincodeStubInterface) StateExists {
+	return func(no string) (bool, error) {
 		existing, err := APIstub.GetState(no)
 		if err != nil {
 			logger.Error(fmt.Sprintf("APIstub.GetState Error. error = %s\n", err))
-			return "", err
-		} else if existing != nil {
-			logger.Warning(fmt.Sprintf("this no exists, no = %s\n", no))
-		} else {
-			break
+			return false, err
 		}
+		return existing != nil, nil
 	}
-	return no, nil
 }
 
-// GetEventNo : return a unique Event No.
-func GetEventNo(APIstub shim.ChaincodeStubInterface) (string, error) {
+func getUniqueNo(exists StateExists, generate func() string) (string, error) {
 	var no string
 	for {
-		no = getRandomString(16, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
-		existing, err := APIstub.GetState(no)
+		no = generate()
+		found, err := exists(no)
 		if err != nil {
-			logger.Error(fmt.Sprintf("APIstub.GetState Error. error = %s\n", err))
 			return "", err
-		} else if existing != nil {
+		} else if found {
 			logger.Warning(fmt.Sprintf("this no exists, no = %s\n", no))
 		} else {
 			break
@@ -78,3 +73,45 @@ func GetEventNo(APIstub shim.ChaincodeStubInterface) (string, error) {
 	}
 	return no, nil
 }
+
+func randomString(n int, letterBytes string) func() string {
+	return func() string {
+		return getRandomString(n, letterBytes)
+	}
+}
+
+// luhnCheckDigit : return the Luhn check digit of a string of digits.
+func luhnCheckDigit(digits string) byte {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		// the rightmost digit is doubled because the check digit is appended to its right
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// GetAccountNo : return a unique Account No.
+//    the last of the 16 digits is the Luhn check digit of the first 15 digits.
+func GetAccountNo(exists StateExists) (string, error) {
+	return getUniqueNo(exists, func() string {
+		body := getRandomString(15, "0123456789")
+		return body + string(luhnCheckDigit(body))
+	})
+}
+
+// GetEventNo : return a unique Event No.
+func GetEventNo(exists StateExists) (string, error) {
+	return getUniqueNo(exists, randomString(16, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"))
+}
+
+// GetJournalNo : return a unique Journal No.
+func GetJournalNo(exists StateExists) (string, error) {
+	return getUniqueNo(exists, randomString(16, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"))
+}
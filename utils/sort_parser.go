@@ -0,0 +1,73 @@
+/*
+ Package utils provides some utility functions.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortIndex : a CouchDB index (under META-INF/statedb/couchdb/indexes) which supports sorting by a field.
+type SortIndex struct {
+	Field     string
+	DesignDoc string
+	Name      string
+}
+
+// Sort : a parsed sort option.
+type Sort struct {
+	Field string
+	Order string
+	Index SortIndex
+}
+
+// GetSort : convert 'field', 'field:asc' or 'field:desc' to Sort, and refuse the field no index supports
+func GetSort(sortStr string, indexes []SortIndex) (*Sort, error) {
+	field, order := sortStr, "asc"
+	if i := strings.Index(sortStr, ":"); i >= 0 {
+		field, order = sortStr[:i], sortStr[i+1:]
+	}
+	if order != "asc" && order != "desc" {
+		msg := fmt.Sprintf("sort order is neither asc nor desc, sort = %s", sortStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return nil, warning
+	}
+	supported := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		if index.Field == field {
+			return &Sort{Field: field, Order: order, Index: index}, nil
+		}
+		supported = append(supported, index.Field)
+	}
+	msg := fmt.Sprintf("no index supports this sort, sort = %s, supported fields = %s", sortStr, supported)
+	warning := &WarningResult{StatusCode: 400, Message: msg}
+	return nil, warning
+}
+
+// Apply : add the sort and use_index to a CouchDB query, and return whether CouchDB can sort it.
+//    CouchDB uses an index only when the selector has all of its fields,
+//    so the sort field is added to the selector when it is missing.
+//    when the query already uses another index chosen for its selector, the query is left as it is
+//    and false is returned, because CouchDB sorts only by the fields of the index in use.
+func (s *Sort) Apply(query map[string]interface{}) bool {
+	if index, ok := query["use_index"].([]string); ok && index[len(index)-1] != s.Index.Name {
+		return false
+	}
+	selector := query["selector"].(map[string]interface{})
+	if _, ok := selector[s.Field]; !ok {
+		selector[s.Field] = map[string]interface{}{
+			"$gt": nil,
+		}
+	}
+	query["sort"] = []interface{}{
+		map[string]interface{}{"model_type": s.Order},
+		map[string]interface{}{s.Field: s.Order},
+	}
+	query["use_index"] = []string{"_design/" + s.Index.DesignDoc, s.Index.Name}
+	return true
+}
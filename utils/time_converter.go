@@ -0,0 +1,100 @@
+/*
+ Package utils provides some utility functions.
+
+ Copyright Nobuyuki Matsui<nobuyuki.matsui>.
+
+ SPDX-License-Identifier: Apache-2.0
+*/
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// DateLayout : the layout of date arguments.
+const DateLayout = "2006-01-02"
+
+// TimestampLayout : the layout of timestamps stored in state objects.
+//    the layout has fixed width in UTC, so that timestamps can be compared as strings.
+const TimestampLayout = "2006-01-02T15:04:05.000Z"
+
+// GetDate : convert date string (YYYY-MM-DD) to time.Time and validate it
+func GetDate(dateStr string) (time.Time, error) {
+	date, err := time.Parse(DateLayout, dateStr)
+	if err != nil {
+		msg := fmt.Sprintf("date is not formatted as %s, date = %s", DateLayout, dateStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return date, warning
+	}
+	return date, nil
+}
+
+// GetTime : convert RFC3339 timestamp or date string to the stored timestamp layout and validate it
+func GetTime(timeStr string) (string, error) {
+	t, err := time.Parse(time.RFC3339Nano, timeStr)
+	if err != nil {
+		t, err = time.Parse(DateLayout, timeStr)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("time is not formatted as RFC3339 or %s, time = %s", DateLayout, timeStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return "", warning
+	}
+	return t.UTC().Format(TimestampLayout), nil
+}
+
+// GetTxTime : return the timestamp (UTC) of the transaction.
+func GetTxTime(APIstub shim.ChaincodeStubInterface) (time.Time, error) {
+	ts, err := APIstub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC(), nil
+}
+
+// GetTimestamp : return the timestamp of the transaction formatted as TimestampLayout.
+func GetTimestamp(APIstub shim.ChaincodeStubInterface) (string, error) {
+	t, err := GetTxTime(APIstub)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(TimestampLayout), nil
+}
+
+// GetCreatedTimestamp : return the timestamp of the transaction which created a key, formatted as TimestampLayout.
+//    it is used to backfill the timestamp of a state object stored before the timestamp was recorded.
+func GetCreatedTimestamp(APIstub shim.ChaincodeStubInterface, key string) (string, error) {
+	resultsIterator, err := APIstub.GetHistoryForKey(key)
+	if err != nil {
+		return "", err
+	}
+	defer resultsIterator.Close()
+
+	var created *time.Time
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return "", err
+		}
+		t := time.Unix(response.Timestamp.Seconds, int64(response.Timestamp.Nanos)).UTC()
+		if created == nil || t.Before(*created) {
+			created = &t
+		}
+	}
+	if created == nil {
+		return "", fmt.Errorf("no history of the key, key = %s", key)
+	}
+	return created.Format(TimestampLayout), nil
+}
+
+// GetTxDate : return the date (UTC) of the transaction.
+func GetTxDate(APIstub shim.ChaincodeStubInterface) (time.Time, error) {
+	t, err := GetTxTime(APIstub)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
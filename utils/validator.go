@@ -8,31 +8,36 @@
 package utils
 
 import (
-	"encoding/json"
 	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"golang.org/x/text/unicode/norm"
 
 	"github.com/nmatsui/fabric-payment-sample-chaincode/models"
+	"github.com/nmatsui/fabric-payment-sample-chaincode/params"
 )
 
-// GetAccount : get an account from state db using account no.
-func GetAccount(APIstub shim.ChaincodeStubInterface, no string) (*models.Account, error) {
-	var account = new(models.Account)
-	accountBytes, err := APIstub.GetState(no)
-	if err != nil {
-		return account, err
-	} else if accountBytes == nil {
-		msg := fmt.Sprintf("Account does not exist, no = %s", no)
-		warning := &WarningResult{StatusCode: 404, Message: msg}
-		return account, warning
-	}
-	if err := json.Unmarshal(accountBytes, account); err != nil {
-		return account, err
-	}
-	return account, nil
-}
+// length limits of the texts, counted in runes after the normalization
+const (
+	MaxNameLength = 100
+	MaxMemoLength = 256
+)
+
+// MaxReferenceLength : the max length of an external reference, which fits EndToEndId (Max35Text) of camt.053.
+const MaxReferenceLength = 35
+
+var (
+	accountNoPattern = regexp.MustCompile(`^[0-9]{16}$`)
+	eventNoPattern   = regexp.MustCompile(`^[a-zA-Z0-9]{16}$`)
+	referencePattern = regexp.MustCompile(`^([!-~]([ -~]*[!-~])?)?$`)
+)
+
+// nameSymbols : the symbols which a name can contain besides letters, marks, numbers and spaces.
+const nameSymbols = "-'.,&()/"
 
 // GetAmount : convert amount to int and validate it
 func GetAmount(amountStr string) (int, error) {
@@ -50,3 +55,172 @@ func GetAmount(amountStr string) (int, error) {
 	}
 	return amount, nil
 }
+
+// GetOverdraftLimit : convert overdraft limit to int and validate it
+func GetOverdraftLimit(limitStr string) (int, error) {
+	var limit int
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil {
+		msg := fmt.Sprintf("overdraft limit is not integer, overdraft_limit = %s", limitStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return limit, warning
+	}
+	if limit < 0 {
+		msg := fmt.Sprintf("overdraft limit is less than zero, overdraft_limit = %d", limit)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return limit, warning
+	}
+	return limit, nil
+}
+
+// CheckBalance : validate that the account can pay the amount within its balance and overdraft limit
+func CheckBalance(account *models.Account, amount int) error {
+	if account.Balance+account.OverdraftLimit < amount {
+		msg := fmt.Sprintf("amount is greater than the available balance, amount = %d, balance = %d, overdraft_limit = %d, no = %s", amount, account.Balance, account.OverdraftLimit, account.No)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	return nil
+}
+
+// GetAnnualRate : convert annual rate (basis points) to int and validate it
+func GetAnnualRate(rateStr string) (int, error) {
+	var rate int
+	rate, err := strconv.Atoi(rateStr)
+	if err != nil {
+		msg := fmt.Sprintf("annual rate is not integer, annual_rate = %s", rateStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return rate, warning
+	}
+	if rate < 0 {
+		msg := fmt.Sprintf("annual rate is less than zero, annual_rate = %d", rate)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return rate, warning
+	}
+	return rate, nil
+}
+
+// GetPagination : convert limit and skip to int and validate them
+func GetPagination(limitStr string, skipStr string) (int, int, error) {
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 || limit > params.MaxPageSize {
+		msg := fmt.Sprintf("limit is not an integer between 1 and %d, limit = %s", params.MaxPageSize, limitStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return 0, 0, warning
+	}
+	skip, err := strconv.Atoi(skipStr)
+	if err != nil || skip < 0 {
+		msg := fmt.Sprintf("skip is not a non-negative integer, skip = %s", skipStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return 0, 0, warning
+	}
+	return limit, skip, nil
+}
+
+// CheckAccountNo : validate the format of an account no
+func CheckAccountNo(no string) error {
+	if !accountNoPattern.MatchString(no) {
+		msg := fmt.Sprintf("account no is not 16 digits, no = %q", no)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	return nil
+}
+
+// CheckAccountNoCheckDigit : validate the format and the Luhn check digit of an account no
+//    this catches every mistyped single digit and most transposed adjacent digits before any state lookup.
+func CheckAccountNoCheckDigit(no string) error {
+	if err := CheckAccountNo(no); err != nil {
+		return err
+	}
+	if no[len(no)-1] != luhnCheckDigit(no[:len(no)-1]) {
+		msg := fmt.Sprintf("account no has an invalid check digit, no = %q", no)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	return nil
+}
+
+// CheckEventNo : validate the format of an event no
+func CheckEventNo(no string) error {
+	if !eventNoPattern.MatchString(no) {
+		msg := fmt.Sprintf("event no is not 16 alphanumeric characters, event_no = %q", no)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	return nil
+}
+
+// CheckReference : validate the length and characters of an external reference
+//    a reference can be empty, and is printable ASCII without leading or trailing spaces.
+func CheckReference(reference string) error {
+	if len(reference) > MaxReferenceLength {
+		msg := fmt.Sprintf("reference is longer than %d characters, length = %d", MaxReferenceLength, len(reference))
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	if !referencePattern.MatchString(reference) {
+		msg := fmt.Sprintf("reference is not printable ASCII without leading or trailing spaces, reference = %q", reference)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return warning
+	}
+	return nil
+}
+
+// NormalizeText : return the text in Unicode Normalization Form C.
+//    names and memos are stored normalized, so that the same text is always stored as the same bytes.
+func NormalizeText(text string) string {
+	return norm.NFC.String(text)
+}
+
+// GetName : normalize a name and validate its length and characters
+func GetName(nameStr string) (string, error) {
+	if !utf8.ValidString(nameStr) {
+		msg := fmt.Sprintf("name is not valid UTF-8, name = %q", nameStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return "", warning
+	}
+	name := NormalizeText(nameStr)
+	if length := utf8.RuneCountInString(name); length == 0 || length > MaxNameLength {
+		msg := fmt.Sprintf("name is not between 1 and %d characters, length = %d", MaxNameLength, length)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return "", warning
+	}
+	if strings.TrimFunc(name, unicode.IsSpace) != name {
+		msg := fmt.Sprintf("name has leading or trailing spaces, name = %q", name)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return "", warning
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Zs, r) && !strings.ContainsRune(nameSymbols, r) {
+			msg := fmt.Sprintf("name has an invalid character, character = %q, name = %q", r, name)
+			warning := &WarningResult{StatusCode: 400, Message: msg}
+			return "", warning
+		}
+	}
+	return name, nil
+}
+
+// GetMemo : normalize a memo and validate its length and characters
+//    a memo can be empty.
+func GetMemo(memoStr string) (string, error) {
+	if !utf8.ValidString(memoStr) {
+		msg := fmt.Sprintf("memo is not valid UTF-8, memo = %q", memoStr)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return "", warning
+	}
+	memo := NormalizeText(memoStr)
+	if length := utf8.RuneCountInString(memo); length > MaxMemoLength {
+		msg := fmt.Sprintf("memo is longer than %d characters, length = %d", MaxMemoLength, length)
+		warning := &WarningResult{StatusCode: 400, Message: msg}
+		return "", warning
+	}
+	for _, r := range memo {
+		if unicode.IsControl(r) {
+			msg := fmt.Sprintf("memo has a control character, character = %q", r)
+			warning := &WarningResult{StatusCode: 400, Message: msg}
+			return "", warning
+		}
+	}
+	return memo, nil
+}